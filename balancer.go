@@ -0,0 +1,344 @@
+package interserviceclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultMaxEndpointFailures is the number of consecutive failures after which an endpoint
+	// is ejected
+	defaultMaxEndpointFailures = 5
+
+	// defaultEjectionTime is how long an ejected endpoint is left out of the rotation
+	defaultEjectionTime = 30 * time.Second
+)
+
+// Endpoint is an address at which a dependency serves requests
+type Endpoint struct {
+	// The root domain of the endpoint e.g `https://profile.bewell.co.ke`
+	URL string `yaml:"url"`
+
+	// The share of requests sent to the endpoint relative to the others when using the
+	// WeightedBalancing strategy. It defaults to 1
+	Weight int `yaml:"weight,omitempty"`
+}
+
+// BalancingStrategy decides which endpoint of a dependency serves a request
+type BalancingStrategy string
+
+const (
+	// RoundRobinBalancing sends requests to each endpoint in turn
+	RoundRobinBalancing BalancingStrategy = "round_robin"
+
+	// LeastOutstandingBalancing sends requests to the endpoint with the fewest requests in flight
+	LeastOutstandingBalancing BalancingStrategy = "least_outstanding"
+
+	// WeightedBalancing spreads requests across the endpoints in proportion to their weights
+	WeightedBalancing BalancingStrategy = "weighted"
+)
+
+// WithBalancingStrategy sets how requests are spread across the endpoints of a dependency.
+// The default is RoundRobinBalancing
+func WithBalancingStrategy(strategy BalancingStrategy) ClientOption {
+	return func(o *clientOptions) {
+		o.balancingStrategy = strategy
+	}
+}
+
+// WithEndpointEjection sets the number of consecutive failures, either connection errors or
+// 5xx responses, after which an endpoint is taken out of the rotation and how long it is left
+// out for. The defaults are 5 failures and 30 seconds
+func WithEndpointEjection(maxFailures int, ejectionTime time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.maxEndpointFailures = maxFailures
+		o.ejectionTime = ejectionTime
+	}
+}
+
+// parseEndpoints validates the endpoints of a dependency
+func parseEndpoints(endpoints []Endpoint) ([]*endpointState, error) {
+	states := []*endpointState{}
+	for _, endpoint := range endpoints {
+		u, err := url.Parse(endpoint.URL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint url %q", endpoint.URL)
+		}
+		if endpoint.Weight < 0 {
+			return nil, fmt.Errorf("invalid weight %d for endpoint %s", endpoint.Weight, endpoint.URL)
+		}
+
+		weight := endpoint.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		states = append(states, &endpointState{url: u, weight: weight})
+	}
+	return states, nil
+}
+
+// withRootDomain adds the root domain to the endpoints unless it is already one of them
+func withRootDomain(rootDomain string, endpoints []Endpoint) []Endpoint {
+	for _, endpoint := range endpoints {
+		if strings.TrimSuffix(endpoint.URL, "/") == strings.TrimSuffix(rootDomain, "/") {
+			return endpoints
+		}
+	}
+	return append([]Endpoint{{URL: rootDomain}}, endpoints...)
+}
+
+// endpointState tracks the load and health of an endpoint
+type endpointState struct {
+	url    *url.URL
+	weight int
+
+	// accessed atomically
+	outstanding int64
+
+	// guarded by the balancer lock
+	currentWeight int
+	failures      int
+	ejectedUntil  time.Time
+
+	// open is set when the endpoint is ejected and cleared once it serves a request
+	// successfully, like a circuit breaker
+	open bool
+}
+
+// balancingTransport spreads requests across the endpoints of a dependency, which are resolved
+// for each request when there is a resolver. Endpoints that fail repeatedly are ejected for a
+// while, and failed requests are retried on another endpoint. Requests with a body are only
+// retried when the body can be replayed
+type balancingTransport struct {
+	next         http.RoundTripper
+	service      string
+	resolver     Resolver
+	primary      *url.URL
+	endpoints    []*endpointState
+	strategy     BalancingStrategy
+	maxFailures  int
+	ejectionTime time.Duration
+	metrics      *clientMetrics
+	now          func() time.Time
+
+	mu   sync.Mutex
+	turn int
+}
+
+// RoundTrip sends the request to an endpoint, failing over to the others
+func (t *balancingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.resolver != nil {
+		if err := t.resolve(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
+	tried := map[*endpointState]bool{}
+	endpoint := t.pick(tried)
+
+	for {
+		tried[endpoint] = true
+
+		attempt, err := t.attemptRequest(req, endpoint, len(tried) > 1)
+		if err != nil {
+			return nil, err
+		}
+
+		atomic.AddInt64(&endpoint.outstanding, 1)
+		resp, err := t.next.RoundTrip(attempt)
+		atomic.AddInt64(&endpoint.outstanding, -1)
+
+		reason := failureReason(resp, err)
+		t.report(req, endpoint, reason == "")
+		if reason == "" || !canRetry(req, err) {
+			return resp, err
+		}
+
+		next := t.pick(tried)
+		if next == nil {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		t.metrics.recordRetry(req.Context(), reason)
+		endpoint = next
+	}
+}
+
+// resolve updates the endpoints from the resolver, keeping the state of known endpoints
+func (t *balancingTransport) resolve(ctx context.Context) error {
+	resolved, err := t.resolver.Resolve(ctx, t.service)
+	if err != nil {
+		return fmt.Errorf("can't resolve the endpoints of %s: %w", t.service, err)
+	}
+	if len(resolved) == 0 {
+		return fmt.Errorf("no endpoints were resolved for %s", t.service)
+	}
+
+	endpoints, err := parseEndpoints(resolved)
+	if err != nil {
+		return fmt.Errorf("invalid endpoints resolved for %s: %w", t.service, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	known := map[string]*endpointState{}
+	for _, endpoint := range t.endpoints {
+		known[endpoint.url.String()] = endpoint
+	}
+	for i, endpoint := range endpoints {
+		if existing, ok := known[endpoint.url.String()]; ok {
+			existing.weight = endpoint.weight
+			endpoints[i] = existing
+			delete(known, endpoint.url.String())
+		}
+	}
+	// endpoints that are no longer resolved don't count as open
+	for _, removed := range known {
+		if removed.open {
+			t.metrics.recordBreaker(ctx, removed.url.Host, false)
+		}
+	}
+	t.endpoints = endpoints
+	return nil
+}
+
+// attemptRequest returns a copy of the request addressed to the endpoint
+func (t *balancingTransport) attemptRequest(req *http.Request, endpoint *endpointState, retry bool) (*http.Request, error) {
+	attempt := req.Clone(req.Context())
+	attempt.URL = rewriteURL(req.URL, t.primary, endpoint.url)
+	attempt.Host = ""
+
+	if retry && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("can't replay request body: %w", err)
+		}
+		attempt.Body = body
+	}
+	return attempt, nil
+}
+
+// rewriteURL moves a request URL from the primary root domain to the endpoint's
+func rewriteURL(u *url.URL, primary *url.URL, endpoint *url.URL) *url.URL {
+	rewritten := *u
+	if u.Scheme != primary.Scheme || u.Host != primary.Host || !strings.HasPrefix(u.Path, primary.Path) {
+		return &rewritten
+	}
+
+	rewritten.Scheme = endpoint.Scheme
+	rewritten.Host = endpoint.Host
+	rewritten.Path = strings.TrimSuffix(endpoint.Path, "/") + "/" +
+		strings.TrimPrefix(strings.TrimPrefix(u.Path, primary.Path), "/")
+	rewritten.RawPath = ""
+	return &rewritten
+}
+
+// failureReason describes why an attempt failed, returning an empty string for a success
+func failureReason(resp *http.Response, err error) string {
+	switch {
+	case err != nil:
+		return "connection_error"
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return "server_error"
+	}
+	return ""
+}
+
+// isIdempotent checks whether repeating a request has the same effect as making it once
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// pick chooses an endpoint that has not been tried using the balancing strategy. When every
+// endpoint is ejected the first attempt is spread across all of them. It returns nil when
+// there is no endpoint left to try
+func (t *balancingTransport) pick(tried map[*endpointState]bool) *endpointState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	candidates := []*endpointState{}
+	for _, endpoint := range t.endpoints {
+		if !tried[endpoint] && !now.Before(endpoint.ejectedUntil) {
+			candidates = append(candidates, endpoint)
+		}
+	}
+	if len(candidates) == 0 {
+		if len(tried) > 0 {
+			return nil
+		}
+		candidates = t.endpoints
+	}
+
+	switch t.strategy {
+	case LeastOutstandingBalancing:
+		t.turn++
+		best := candidates[t.turn%len(candidates)]
+		for _, endpoint := range candidates {
+			if atomic.LoadInt64(&endpoint.outstanding) < atomic.LoadInt64(&best.outstanding) {
+				best = endpoint
+			}
+		}
+		return best
+
+	case WeightedBalancing:
+		// smooth weighted round robin
+		total := 0
+		var best *endpointState
+		for _, endpoint := range candidates {
+			endpoint.currentWeight += endpoint.weight
+			total += endpoint.weight
+			if best == nil || endpoint.currentWeight > best.currentWeight {
+				best = endpoint
+			}
+		}
+		best.currentWeight -= total
+		return best
+
+	default:
+		t.turn++
+		return candidates[t.turn%len(candidates)]
+	}
+}
+
+// report records the outcome of an attempt, ejecting endpoints that keep failing
+func (t *balancingTransport) report(req *http.Request, endpoint *endpointState, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if ok {
+		endpoint.failures = 0
+		if endpoint.open {
+			endpoint.open = false
+			t.metrics.recordBreaker(req.Context(), endpoint.url.Host, false)
+		}
+		return
+	}
+
+	endpoint.failures++
+	if t.maxFailures > 0 && endpoint.failures >= t.maxFailures {
+		endpoint.failures = 0
+		endpoint.ejectedUntil = t.now().Add(t.ejectionTime)
+		t.metrics.recordEjection(req.Context(), endpoint.url.Host)
+		if !endpoint.open {
+			endpoint.open = true
+			t.metrics.recordBreaker(req.Context(), endpoint.url.Host, true)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// endpointServer is a test server that counts the requests it receives
+type endpointServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	hits   int
+	bodies []string
+}
+
+func newEndpointServer(t *testing.T, status int) *endpointServer {
+	s := &endpointServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.hits++
+		s.bodies = append(s.bodies, string(body))
+		s.mu.Unlock()
+
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *endpointServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits
+}
+
+// deadEndpoint returns the URL of a server that refuses connections
+func deadEndpoint() string {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	return srv.URL
+}
+
+func TestInterServiceClient_RoundRobin(t *testing.T) {
+	primary := newEndpointServer(t, http.StatusOK)
+	fallback := newEndpointServer(t, http.StatusOK)
+
+	client, err := interserviceclient.NewInterserviceClient(interserviceclient.ISCService{
+		Name:       "profile",
+		RootDomain: primary.URL,
+		Endpoints:  []interserviceclient.Endpoint{{URL: fallback.URL}},
+	})
+	assert.Nil(t, err)
+
+	for i := 0; i < 4; i++ {
+		assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+	}
+	assert.Equal(t, 2, primary.count())
+	assert.Equal(t, 2, fallback.count())
+}
+
+func TestInterServiceClient_WeightedBalancing(t *testing.T) {
+	heavy := newEndpointServer(t, http.StatusOK)
+	light := newEndpointServer(t, http.StatusOK)
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{
+			Name: "profile",
+			Endpoints: []interserviceclient.Endpoint{
+				{URL: heavy.URL, Weight: 3},
+				{URL: light.URL, Weight: 1},
+			},
+		},
+		interserviceclient.WithBalancingStrategy(interserviceclient.WeightedBalancing),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, heavy.URL, client.RequestRootDomain)
+
+	for i := 0; i < 8; i++ {
+		assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+	}
+	assert.Equal(t, 6, heavy.count())
+	assert.Equal(t, 2, light.count())
+}
+
+func TestInterServiceClient_LeastOutstandingBalancing(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan string, 2)
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			received <- name
+			if r.URL.Path == "/slow" {
+				<-release
+			}
+		}
+	}
+	a := httptest.NewServer(handler("a"))
+	defer a.Close()
+	b := httptest.NewServer(handler("b"))
+	defer b.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{
+			Name:      "profile",
+			Endpoints: []interserviceclient.Endpoint{{URL: a.URL}, {URL: b.URL}},
+		},
+		interserviceclient.WithBalancingStrategy(interserviceclient.LeastOutstandingBalancing),
+	)
+	assert.Nil(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- interserviceclient.Call(context.Background(), client, http.MethodGet, "slow", nil)
+	}()
+	busy := <-received
+
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "fast", nil))
+		assert.NotEqual(t, busy, <-received)
+	}
+
+	close(release)
+	assert.Nil(t, <-done)
+}
+
+func TestInterServiceClient_Failover(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	healthy := newEndpointServer(t, http.StatusOK)
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{
+			Name:       "profile",
+			RootDomain: deadEndpoint(),
+			Endpoints:  []interserviceclient.Endpoint{{URL: healthy.URL}},
+		},
+		interserviceclient.WithEndpointEjection(2, time.Minute),
+		interserviceclient.WithMeterProvider(meterProvider),
+	)
+	assert.Nil(t, err)
+
+	// the request body is replayed on the healthy endpoint
+	for i := 0; i < 4; i++ {
+		err := interserviceclient.Call(
+			context.Background(), client, http.MethodPost, "users", map[string]string{"name": "Test"},
+		)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 4, healthy.count())
+	assert.Equal(t, `{"name":"Test"}`, healthy.bodies[0])
+
+	metrics := collectMetrics(t, reader)
+	// the dead endpoint is tried twice before it is ejected
+	assert.Equal(t, int64(2), sumFor(t, metrics["isc.client.retries"], attribute.String("isc.reason", "connection_error")))
+	assert.Equal(t, int64(1), sumFor(t, metrics["isc.client.ejections"], attribute.String("isc.dependency", "profile")))
+}
+
+func TestInterServiceClient_BreakerStateMetric(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	var status int32 = http.StatusServiceUnavailable
+	var flakyHits int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&flakyHits, 1)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer flaky.Close()
+	healthy := newEndpointServer(t, http.StatusOK)
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{
+			Name:       "profile",
+			RootDomain: flaky.URL,
+			Endpoints:  []interserviceclient.Endpoint{{URL: healthy.URL}},
+		},
+		interserviceclient.WithEndpointEjection(1, 20*time.Millisecond),
+		interserviceclient.WithMeterProvider(meterProvider),
+	)
+	assert.Nil(t, err)
+
+	open := func() int64 {
+		return sumFor(t, collectMetrics(t, reader)["isc.client.breakers.open"], attribute.String("isc.dependency", "profile"))
+	}
+
+	// the flaky endpoint fails and its breaker opens
+	for atomic.LoadInt32(&flakyHits) == 0 {
+		assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+	}
+	assert.Equal(t, int64(1), open())
+
+	// the breaker closes once the endpoint serves a request after its ejection
+	atomic.StoreInt32(&status, http.StatusOK)
+	time.Sleep(30 * time.Millisecond)
+	for atomic.LoadInt32(&flakyHits) == 1 {
+		assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+	}
+	assert.Equal(t, int64(0), open())
+}
+
+func TestInterServiceClient_FailoverOnServerError(t *testing.T) {
+	failing := newEndpointServer(t, http.StatusServiceUnavailable)
+	healthy := newEndpointServer(t, http.StatusOK)
+
+	client, err := interserviceclient.NewInterserviceClient(interserviceclient.ISCService{
+		Name:      "profile",
+		Endpoints: []interserviceclient.Endpoint{{URL: failing.URL}, {URL: healthy.URL}},
+	})
+	assert.Nil(t, err)
+
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+	}
+	assert.Equal(t, 1, failing.count())
+	assert.Equal(t, 2, healthy.count())
+
+	// requests that are not idempotent are not repeated after reaching the dependency
+	failures := 0
+	for i := 0; i < 2; i++ {
+		if err := interserviceclient.Call(context.Background(), client, http.MethodPost, "users", nil); err != nil {
+			failures++
+		}
+	}
+	assert.Equal(t, 1, failures)
+	assert.Equal(t, 2, failing.count())
+}
+
+func TestInterServiceClient_EndpointPathPrefix(t *testing.T) {
+	paths := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.RequestURI()
+	}))
+	defer srv.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(interserviceclient.ISCService{
+		Name:       "profile",
+		RootDomain: srv.URL + "/v1",
+		Endpoints:  []interserviceclient.Endpoint{{URL: srv.URL + "/regional/v1"}},
+	})
+	assert.Nil(t, err)
+
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, interserviceclient.Call(
+			context.Background(), client, http.MethodGet, "users", nil,
+			interserviceclient.WithQueryParam("page", "2"),
+		))
+	}
+	assert.ElementsMatch(t, []string{"/v1/users?page=2", "/regional/v1/users?page=2"}, []string{<-paths, <-paths})
+}
+
+func TestNewInterserviceClient_InvalidEndpoints(t *testing.T) {
+	_, err := interserviceclient.NewInterserviceClient(interserviceclient.ISCService{
+		Name:      "profile",
+		Endpoints: []interserviceclient.Endpoint{{URL: "https://profile.example.com"}, {URL: "profile"}},
+	})
+	assert.NotNil(t, err)
+
+	_, err = interserviceclient.NewInterserviceClient(interserviceclient.ISCService{
+		Name:      "profile",
+		Endpoints: []interserviceclient.Endpoint{{URL: "https://profile.example.com", Weight: -1}},
+	})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,428 @@
+package interserviceclient
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// CacheStatusHeader is added to responses served by a client with a cache. Its value is
+	// `HIT`, `REVALIDATED` or `MISS`
+	CacheStatusHeader = "X-ISC-Cache"
+
+	// defaultCacheSize is the number of response body bytes cached for a dependency
+	defaultCacheSize = 10 << 20
+)
+
+// cache results recorded in metrics and the CacheStatusHeader header
+const (
+	cacheHit         = "HIT"
+	cacheRevalidated = "REVALIDATED"
+	cacheMiss        = "MISS"
+)
+
+// CachedResponse is a response held in a CacheStore
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	// The values of the request headers named in the response's Vary header
+	VaryHeaders map[string]string
+
+	// The time after which the response must be revalidated before it is used
+	Expires time.Time
+}
+
+// size is the number of bytes counted against the store size limit
+func (r *CachedResponse) size() int64 {
+	return int64(len(r.Body))
+}
+
+// CacheStore holds the responses cached by a client. Implementations must be safe for
+// concurrent use
+type CacheStore interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse)
+	Delete(key string)
+}
+
+// LRUCacheStore is an in-memory CacheStore that evicts the least recently used responses
+// once the size of the cached bodies exceeds its limit
+type LRUCacheStore struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// lruEntry is an element of the LRU list
+type lruEntry struct {
+	key  string
+	resp *CachedResponse
+}
+
+// NewLRUCacheStore creates an in-memory store that holds up to maxBytes of response bodies
+func NewLRUCacheStore(maxBytes int64) *LRUCacheStore {
+	return &LRUCacheStore{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  map[string]*list.Element{},
+	}
+}
+
+// Get returns the response cached under the key
+func (s *LRUCacheStore) Get(key string) (*CachedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry).resp, true
+}
+
+// Set caches the response under the key. Responses larger than the store are not cached
+func (s *LRUCacheStore) Set(key string, resp *CachedResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(key)
+	if resp.size() > s.maxBytes {
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(&lruEntry{key: key, resp: resp})
+	s.size += resp.size()
+
+	for s.size > s.maxBytes {
+		s.remove(s.order.Back().Value.(*lruEntry).key)
+	}
+}
+
+// Delete removes the response cached under the key
+func (s *LRUCacheStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(key)
+}
+
+// Len returns the number of cached responses
+func (s *LRUCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.order.Len()
+}
+
+// remove deletes an entry. The caller must hold the lock
+func (s *LRUCacheStore) remove(key string) {
+	elem, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	s.order.Remove(elem)
+	delete(s.entries, key)
+	s.size -= elem.Value.(*lruEntry).resp.size()
+}
+
+// WithCache caches the responses to GET requests in memory, holding up to maxBytes of response
+// bodies for the dependency. A size of zero or less uses the default of 10MB. Responses to
+// requests made with a token source or per request headers are only cached when the
+// dependency marks them public, and are never served to other callers
+func WithCache(maxBytes int64) ClientOption {
+	return func(o *clientOptions) {
+		if maxBytes <= 0 {
+			maxBytes = defaultCacheSize
+		}
+		o.cacheStore = NewLRUCacheStore(maxBytes)
+	}
+}
+
+// WithCacheStore caches the responses to GET requests in the supplied store. A store can be
+// shared by clients since the cache keys include the dependency name
+func WithCacheStore(store CacheStore) ClientOption {
+	return func(o *clientOptions) {
+		o.cacheStore = store
+	}
+}
+
+// cacheTransport serves GET requests from a cache, following the Cache-Control, ETag,
+// Last-Modified and Vary headers of the dependency's responses.
+//
+// The cache is shared by every caller of the client. Responses marked private are never
+// cached, and responses to requests made with a caller's own credentials or headers, from a
+// token source or WithHeader, are only cached when they are marked public and are only served
+// to requests with the same credentials and headers
+type cacheTransport struct {
+	next    http.RoundTripper
+	service string
+	store   CacheStore
+	metrics *clientMetrics
+	now     func() time.Time
+
+	// vary holds the request headers named by the Vary header of the responses cached for
+	// each URL, which are part of the cache key
+	vary sync.Map
+}
+
+// RoundTrip serves fresh responses from the cache, revalidates stale ones and caches new ones
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqDirectives := parseCacheControl(req.Header)
+	if req.Method != http.MethodGet || reqDirectives.has("no-store") {
+		return t.next.RoundTrip(req)
+	}
+
+	base := t.baseKey(req)
+	key := t.key(base, req)
+	cached, ok := t.store.Get(key)
+	if ok && !cached.matchesVary(req) {
+		cached, ok = nil, false
+	}
+
+	if ok && !reqDirectives.has("no-cache") && t.now().Before(cached.Expires) {
+		t.metrics.recordCache(req.Context(), cacheHit)
+		return cached.response(req, cacheHit), nil
+	}
+
+	if ok {
+		req = revalidationRequest(req, cached)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		refreshed := *cached
+		refreshed.Header = cached.Header.Clone()
+		for _, name := range []string{"Cache-Control", "Date", "Expires", "ETag", "Last-Modified"} {
+			if value := resp.Header.Get(name); value != "" {
+				refreshed.Header.Set(name, value)
+			}
+		}
+		refreshed.Expires = t.expires(refreshed.Header)
+		t.store.Set(key, &refreshed)
+
+		t.metrics.recordCache(req.Context(), cacheRevalidated)
+		return refreshed.response(req, cacheRevalidated), nil
+	}
+
+	t.metrics.recordCache(req.Context(), cacheMiss)
+	resp.Header.Set(CacheStatusHeader, cacheMiss)
+
+	if !t.storable(req, resp) {
+		if ok {
+			t.store.Delete(key)
+		}
+		return resp, nil
+	}
+	if names := varyNames(resp.Header); len(names) > 0 {
+		t.vary.Store(base, names)
+		key = varyKey(base, req, names)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	header.Del(CacheStatusHeader)
+	t.store.Set(key, &CachedResponse{
+		StatusCode:  resp.StatusCode,
+		Header:      header,
+		Body:        body,
+		VaryHeaders: varyHeaders(req, resp.Header),
+		Expires:     t.expires(resp.Header),
+	})
+
+	return resp, nil
+}
+
+// baseKey identifies the resource requested by a caller. Requests made with a caller's own
+// credentials or headers are kept apart from those of other callers
+func (t *cacheTransport) baseKey(req *http.Request) string {
+	key := fmt.Sprintf("%s %s", t.service, req.URL.String())
+	if identity := identityFromRequest(req); identity != "" {
+		key += " " + identity
+	}
+	return key
+}
+
+// key returns the cache key of a request, including the request headers that cached
+// responses for the resource vary on
+func (t *cacheTransport) key(base string, req *http.Request) string {
+	names, ok := t.vary.Load(base)
+	if !ok {
+		return base
+	}
+	return varyKey(base, req, names.([]string))
+}
+
+// storable checks whether a response may be cached. Only successful responses that are either
+// fresh for some time or can be revalidated are cached. Responses to caller specific requests
+// must be marked public
+func (t *cacheTransport) storable(req *http.Request, resp *http.Response) bool {
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	directives := parseCacheControl(resp.Header)
+	if directives.has("no-store") || directives.has("private") || resp.Header.Get("Vary") == "*" {
+		return false
+	}
+	if identityFromRequest(req) != "" && !directives.has("public") {
+		return false
+	}
+	return t.expires(resp.Header).After(t.now()) ||
+		resp.Header.Get("ETag") != "" ||
+		resp.Header.Get("Last-Modified") != ""
+}
+
+// expires returns the time until which a response is fresh, using the max-age directive or
+// the Expires header. Responses marked no-cache must always be revalidated
+func (t *cacheTransport) expires(header http.Header) time.Time {
+	now := t.now()
+	directives := parseCacheControl(header)
+	if directives.has("no-cache") {
+		return now
+	}
+
+	if value, ok := directives["max-age"]; ok {
+		maxAge, err := strconv.Atoi(value)
+		if err != nil {
+			return now
+		}
+		age, _ := strconv.Atoi(header.Get("Age"))
+		return now.Add(time.Duration(maxAge-age) * time.Second)
+	}
+
+	if value := header.Get("Expires"); value != "" {
+		expires, err := http.ParseTime(value)
+		if err != nil {
+			return now
+		}
+		if date, err := http.ParseTime(header.Get("Date")); err == nil {
+			return now.Add(expires.Sub(date))
+		}
+		return expires
+	}
+
+	return now
+}
+
+// revalidationRequest returns a copy of the request made conditional on the cached response
+func revalidationRequest(req *http.Request, cached *CachedResponse) *http.Request {
+	req = req.Clone(req.Context())
+	if etag := cached.Header.Get("ETag"); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	return req
+}
+
+// response creates a response to the request from the cached one
+func (r *CachedResponse) response(req *http.Request, status string) *http.Response {
+	header := r.Header.Clone()
+	header.Set(CacheStatusHeader, status)
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// matchesVary checks that the request has the header values the cached response varies on
+func (r *CachedResponse) matchesVary(req *http.Request) bool {
+	for name, value := range r.VaryHeaders {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// varyHeaders returns the values of the request headers named in the Vary response header
+func varyHeaders(req *http.Request, header http.Header) map[string]string {
+	values := map[string]string{}
+	for _, name := range varyNames(header) {
+		values[name] = req.Header.Get(name)
+	}
+	return values
+}
+
+// varyNames returns the sorted request headers named in the Vary response header
+func varyNames(header http.Header) []string {
+	names := []string{}
+	for _, vary := range header.Values("Vary") {
+		for _, name := range strings.Split(vary, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// varyKey adds the values of the named request headers to a cache key
+func varyKey(base string, req *http.Request, names []string) string {
+	var b strings.Builder
+	b.WriteString(base)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n%s: %s", name, strings.Join(req.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// cacheControl holds the directives of a Cache-Control header
+type cacheControl map[string]string
+
+// has checks for a directive
+func (c cacheControl) has(directive string) bool {
+	_, ok := c[directive]
+	return ok
+}
+
+// parseCacheControl parses the Cache-Control headers
+func parseCacheControl(header http.Header) cacheControl {
+	directives := cacheControl{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name == "" {
+				continue
+			}
+			directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+		}
+	}
+	return directives
+}
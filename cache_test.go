@@ -0,0 +1,200 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestInterServiceClient_Cache(t *testing.T) {
+	ctx := context.Background()
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	hits := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store, max-age=60")
+		}
+		_, _ = w.Write([]byte(`{"name": "Test"}`))
+	}))
+	defer srv.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithCache(0),
+		interserviceclient.WithMeterProvider(meterProvider),
+	)
+	assert.Nil(t, err)
+
+	type profile struct {
+		Name string `json:"name"`
+	}
+	for _, path := range []string{"fresh", "etag", "no-store"} {
+		for i := 0; i < 3; i++ {
+			got, err := interserviceclient.Do[profile](ctx, client, http.MethodGet, path, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, "Test", got.Name)
+		}
+	}
+
+	assert.Equal(t, 1, hits["/fresh"])
+	assert.Equal(t, 3, hits["/etag"])
+	assert.Equal(t, 3, hits["/no-store"])
+
+	// the query is part of the cache key and other methods are never cached
+	assert.Nil(t, interserviceclient.Call(ctx, client, http.MethodGet, "fresh?page=2", nil))
+	assert.Nil(t, interserviceclient.Call(ctx, client, http.MethodPost, "fresh", nil))
+	assert.Equal(t, 3, hits["/fresh"])
+
+	resp, err := client.DoRequest(ctx, http.MethodGet, "fresh", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "HIT", resp.Header.Get(interserviceclient.CacheStatusHeader))
+	assert.Nil(t, resp.Body.Close())
+
+	resp, err = client.DoRequest(ctx, http.MethodGet, "fresh", nil, interserviceclient.WithHeader("Cache-Control", "no-cache"))
+	assert.Nil(t, err)
+	assert.Equal(t, "MISS", resp.Header.Get(interserviceclient.CacheStatusHeader))
+	assert.Nil(t, resp.Body.Close())
+
+	lookups := collectMetrics(t, reader)["isc.client.cache"]
+	assert.Equal(t, int64(3), sumFor(t, lookups, attribute.String("isc.cache", "hit")))
+	assert.Equal(t, int64(2), sumFor(t, lookups, attribute.String("isc.cache", "revalidated")))
+	assert.Equal(t, int64(7), sumFor(t, lookups, attribute.String("isc.cache", "miss")))
+}
+
+func TestLRUCacheStore(t *testing.T) {
+	store := interserviceclient.NewLRUCacheStore(10)
+
+	store.Set("a", &interserviceclient.CachedResponse{Body: []byte("1234")})
+	store.Set("b", &interserviceclient.CachedResponse{Body: []byte("1234")})
+	_, ok := store.Get("a")
+	assert.True(t, ok)
+
+	// b is the least recently used response
+	store.Set("c", &interserviceclient.CachedResponse{Body: []byte("1234")})
+	_, ok = store.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, 2, store.Len())
+
+	// responses larger than the store are not cached
+	store.Set("d", &interserviceclient.CachedResponse{Body: []byte("12345678901")})
+	_, ok = store.Get("d")
+	assert.False(t, ok)
+
+	store.Delete("a")
+	assert.Equal(t, 1, store.Len())
+}
+
+// userKey is the context key of the user whose token a test token source returns
+type userKey struct{}
+
+func TestInterServiceClient_CacheCallerCredentials(t *testing.T) {
+	hits := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		switch r.URL.Path {
+		case "/public":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		default:
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		_, _ = w.Write([]byte(`{"name": "` + r.Header.Get("Authorization") + `"}`))
+	}))
+	defer srv.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithCache(0),
+		interserviceclient.WithTokenSource(interserviceclient.TokenSourceFunc(func(ctx context.Context) (string, error) {
+			return ctx.Value(userKey{}).(string), nil
+		})),
+	)
+	assert.Nil(t, err)
+
+	type profile struct {
+		Name string `json:"name"`
+	}
+	alice := context.WithValue(context.Background(), userKey{}, "alice")
+	bob := context.WithValue(context.Background(), userKey{}, "bob")
+
+	// responses to requests made with a caller's token are not cached unless they are public
+	for _, ctx := range []context.Context{alice, bob, alice} {
+		got, err := interserviceclient.Do[profile](ctx, client, http.MethodGet, "profile", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "Bearer "+ctx.Value(userKey{}).(string), got.Name)
+	}
+	assert.Equal(t, 3, hits["/profile"])
+
+	// public responses are cached for each caller
+	for _, ctx := range []context.Context{alice, bob, alice, bob} {
+		got, err := interserviceclient.Do[profile](ctx, client, http.MethodGet, "public", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "Bearer "+ctx.Value(userKey{}).(string), got.Name)
+	}
+	assert.Equal(t, 2, hits["/public"])
+}
+
+func TestInterServiceClient_CachePrivateAndVary(t *testing.T) {
+	hits := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "private, max-age=60")
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "X-Tenant")
+		}
+		_, _ = w.Write([]byte(`{"name": "` + r.Header.Get("X-Tenant") + `"}`))
+	}))
+	defer srv.Close()
+
+	// clients for different tenants share a store
+	store := interserviceclient.NewLRUCacheStore(1 << 20)
+	client := func(tenant string) *interserviceclient.InterServiceClient {
+		c, err := interserviceclient.NewInterserviceClient(
+			interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+			interserviceclient.WithCacheStore(store),
+			interserviceclient.WithDefaultHeaders(map[string]string{"X-Tenant": tenant}),
+		)
+		assert.Nil(t, err)
+		return c
+	}
+	bewell, slade := client("bewell"), client("slade")
+
+	type profile struct {
+		Name string `json:"name"`
+	}
+	for _, path := range []string{"private", "vary"} {
+		for _, c := range []*interserviceclient.InterServiceClient{bewell, slade, bewell, slade} {
+			got, err := interserviceclient.Do[profile](context.Background(), c, http.MethodGet, path, nil)
+			assert.Nil(t, err)
+			want := "bewell"
+			if c == slade {
+				want = "slade"
+			}
+			assert.Equal(t, want, got.Name)
+		}
+	}
+	assert.Equal(t, 4, hits["/private"])
+	assert.Equal(t, 2, hits["/vary"])
+	assert.Equal(t, 2, store.Len())
+}
@@ -0,0 +1,426 @@
+package interserviceclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CassetteMode controls whether a cassette records requests or replays them
+type CassetteMode string
+
+const (
+	// CassetteReplay serves recorded responses and fails requests that were not recorded
+	CassetteReplay CassetteMode = "replay"
+
+	// CassetteRecord sends every request to the dependency and records it, replacing the
+	// interactions that were previously recorded
+	CassetteRecord CassetteMode = "record"
+
+	// CassetteReplayOrRecord replays requests that were recorded and records the rest
+	CassetteReplayOrRecord CassetteMode = "replay_or_record"
+)
+
+// ErrInteractionNotFound is returned by a replaying cassette for requests that were not recorded
+var ErrInteractionNotFound = errors.New("no recorded interaction matches the request")
+
+// RecordedRequest is a request stored in a cassette
+type RecordedRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response stored in a cassette
+type RecordedResponse struct {
+	StatusCode int               `json:"statusCode"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body,omitempty"`
+}
+
+// Interaction is a request and the response it received
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// cassetteFile is the layout of a cassette on disk
+type cassetteFile struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// CassetteMatcher decides whether a recorded request can answer a request. The body is the
+// request body with sensitive values redacted in the same way as the recording
+type CassetteMatcher func(req *http.Request, body string, recorded RecordedRequest) bool
+
+// MatchMethod matches requests with the same method
+func MatchMethod(req *http.Request, body string, recorded RecordedRequest) bool {
+	return req.Method == recorded.Method
+}
+
+// MatchPath matches requests with the same URL path
+func MatchPath(req *http.Request, body string, recorded RecordedRequest) bool {
+	u, err := url.Parse(recorded.URL)
+	return err == nil && u.Path == req.URL.Path
+}
+
+// MatchQuery matches requests with the same query parameters, ignoring the values of redacted ones
+func MatchQuery(req *http.Request, body string, recorded RecordedRequest) bool {
+	u, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	want, got := u.Query(), req.URL.Query()
+	if len(want) != len(got) {
+		return false
+	}
+	for key, values := range want {
+		if len(values) == 1 && values[0] == redactedValue {
+			if _, ok := got[key]; ok {
+				continue
+			}
+			return false
+		}
+		if strings.Join(values, ",") != strings.Join(got[key], ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchBody matches requests with the same body. JSON bodies are compared after redaction
+func MatchBody(req *http.Request, body string, recorded RecordedRequest) bool {
+	return body == recorded.Body
+}
+
+// DefaultCassetteMatchers match requests on their method, path and query
+var DefaultCassetteMatchers = []CassetteMatcher{MatchMethod, MatchPath, MatchQuery}
+
+// CassetteOption configures a Cassette
+type CassetteOption func(*cassetteOptions)
+
+// cassetteOptions holds the settings used to create a Cassette
+type cassetteOptions struct {
+	mode            CassetteMode
+	matchers        []CassetteMatcher
+	transport       http.RoundTripper
+	redactedHeaders []string
+	redactedFields  []string
+	keptFields      []string
+}
+
+// WithCassetteMode sets whether the cassette records or replays. It defaults to
+// CassetteReplayOrRecord
+func WithCassetteMode(mode CassetteMode) CassetteOption {
+	return func(o *cassetteOptions) {
+		o.mode = mode
+	}
+}
+
+// WithCassetteMatchers sets how requests are matched to recorded ones. All matchers must agree.
+// The default is DefaultCassetteMatchers
+func WithCassetteMatchers(matchers ...CassetteMatcher) CassetteOption {
+	return func(o *cassetteOptions) {
+		o.matchers = matchers
+	}
+}
+
+// WithCassetteTransport sets the transport used to send requests that are recorded.
+// The default is http.DefaultTransport
+func WithCassetteTransport(transport http.RoundTripper) CassetteOption {
+	return func(o *cassetteOptions) {
+		o.transport = transport
+	}
+}
+
+// WithCassetteRedactedHeaders adds headers whose values are redacted from recordings
+func WithCassetteRedactedHeaders(headers ...string) CassetteOption {
+	return func(o *cassetteOptions) {
+		o.redactedHeaders = append(o.redactedHeaders, headers...)
+	}
+}
+
+// WithCassetteRedactedFields adds JSON fields and query parameters whose values are redacted
+// from recordings
+func WithCassetteRedactedFields(fields ...string) CassetteOption {
+	return func(o *cassetteOptions) {
+		o.redactedFields = append(o.redactedFields, fields...)
+	}
+}
+
+// WithCassetteUnredactedResponseFields records the values of the fields in response bodies
+// instead of redacting them, for tests that depend on them. Only use it for fields whose
+// recorded values are not live credentials or personal information
+func WithCassetteUnredactedResponseFields(fields ...string) CassetteOption {
+	return func(o *cassetteOptions) {
+		o.keptFields = append(o.keptFields, fields...)
+	}
+}
+
+// Cassette is an http.RoundTripper that records inter service requests and their responses to a
+// file and replays them, so that tests can run without reaching the dependencies. Auth tokens
+// and personally identifiable information are redacted from the recordings.
+// Use it with WithTransport and call Save once the recorded requests have been made
+type Cassette struct {
+	path     string
+	mode     CassetteMode
+	matchers []CassetteMatcher
+	next     http.RoundTripper
+	redactor *redactor
+
+	// responseRedactor redacts recorded response bodies, keeping the fields that were
+	// explicitly left unredacted
+	responseRedactor *redactor
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+	recorded     bool
+}
+
+// NewCassette creates a cassette backed by the file at path, loading the interactions it
+// holds. The file may only be missing when the cassette is allowed to record
+func NewCassette(path string, opts ...CassetteOption) (*Cassette, error) {
+	options := &cassetteOptions{
+		mode:            CassetteReplayOrRecord,
+		matchers:        DefaultCassetteMatchers,
+		transport:       http.DefaultTransport,
+		redactedHeaders: DefaultRedactedHeaders,
+		redactedFields:  DefaultRedactedFields,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	c := &Cassette{
+		path:     path,
+		mode:     options.mode,
+		matchers: options.matchers,
+		next:     options.transport,
+		redactor: newRedactor(options.redactedHeaders, options.redactedFields),
+	}
+	c.responseRedactor = newRedactor(nil, responseFields(options.redactedFields, options.keptFields))
+
+	switch options.mode {
+	case CassetteRecord:
+		return c, nil
+	case CassetteReplay, CassetteReplayOrRecord:
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", options.mode)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && options.mode == CassetteReplayOrRecord {
+			return c, nil
+		}
+		return nil, fmt.Errorf("can't read cassette %s: %w", path, err)
+	}
+
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("can't unmarshal cassette %s: %w", path, err)
+	}
+	c.interactions = file.Interactions
+	c.used = make([]bool, len(file.Interactions))
+
+	return c, nil
+}
+
+// RoundTrip replays the recorded response to the request or sends and records it, depending
+// on the cassette mode
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	redactedBody := c.redactBody(body)
+
+	if c.mode != CassetteRecord {
+		if interaction, ok := c.match(req, redactedBody); ok {
+			return replay(req, interaction.Response), nil
+		}
+		if c.mode == CassetteReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, req.URL.Path)
+		}
+	}
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	c.record(Interaction{
+		Request: RecordedRequest{
+			Method:  req.Method,
+			URL:     c.redactor.redactURL(req.URL),
+			Headers: c.redactor.redactHeaders(req.Header),
+			Body:    redactedBody,
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    c.redactor.redactHeaders(resp.Header),
+			Body:       c.redactResponseBody(respBody),
+		},
+	})
+
+	return resp, nil
+}
+
+// redactBody redacts sensitive values from a request body
+func (c *Cassette) redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	return c.redactor.redactBody(body)
+}
+
+// redactResponseBody redacts sensitive values from a response body
+func (c *Cassette) redactResponseBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	return c.responseRedactor.redactBody(body)
+}
+
+// responseFields returns the redacted fields that are not kept in response bodies
+func responseFields(redacted []string, kept []string) []string {
+	keep := map[string]bool{}
+	for _, field := range kept {
+		keep[fieldKey(field)] = true
+	}
+	fields := []string{}
+	for _, field := range redacted {
+		if !keep[fieldKey(field)] {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// match finds the first unused interaction that matches the request, falling back to the last
+// used one so that repeated requests can be replayed
+func (c *Cassette) match(req *http.Request, body string) (Interaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fallback := -1
+	for i, interaction := range c.interactions {
+		if !c.matches(req, body, interaction.Request) {
+			continue
+		}
+		if !c.used[i] {
+			c.used[i] = true
+			return interaction, true
+		}
+		fallback = i
+	}
+	if fallback >= 0 {
+		return c.interactions[fallback], true
+	}
+	return Interaction{}, false
+}
+
+// matches checks a request against a recorded one using all the matchers
+func (c *Cassette) matches(req *http.Request, body string, recorded RecordedRequest) bool {
+	for _, matcher := range c.matchers {
+		if !matcher(req, body, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+// record adds an interaction to the cassette. The first recording in record mode replaces
+// the interactions loaded from the file
+func (c *Cassette) record(interaction Interaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.recorded && c.mode == CassetteRecord {
+		c.interactions = nil
+		c.used = nil
+	}
+	c.recorded = true
+	c.interactions = append(c.interactions, interaction)
+	c.used = append(c.used, true)
+}
+
+// replay creates a response from a recorded one
+func replay(req *http.Request, recorded RecordedResponse) *http.Response {
+	header := http.Header{}
+	keys := make([]string, 0, len(recorded.Headers))
+	for key := range recorded.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		header.Set(key, recorded.Headers[key])
+	}
+	header.Del("Content-Length")
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}
+}
+
+// Interactions returns the interactions held by the cassette
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Interaction{}, c.interactions...)
+}
+
+// Save writes the cassette to its file if any requests were recorded
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.recorded {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(cassetteFile{Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("can't marshal cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o750); err != nil {
+		return fmt.Errorf("can't create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("can't write cassette %s: %w", c.path, err)
+	}
+	return nil
+}
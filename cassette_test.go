@@ -0,0 +1,143 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCassette_RecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cassettes", "otp.json")
+
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		_, _ = w.Write([]byte(`{"IsVerified": true, "msisdn": "+254711223344"}`))
+	}))
+
+	cassette, err := interserviceclient.NewCassette(path, interserviceclient.WithCassetteMode(interserviceclient.CassetteRecord))
+	assert.Nil(t, err)
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "otp", RootDomain: srv.URL},
+		interserviceclient.WithTransport(cassette),
+	)
+	assert.Nil(t, err)
+
+	verified, err := interserviceclient.VerifyOTP(ctx, "+254711223344", "123456", client)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	assert.Nil(t, cassette.Save())
+	srv.Close()
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	recording := string(data)
+	for _, secret := range []string{"Bearer ", "+254711223344", "123456"} {
+		assert.False(t, strings.Contains(recording, secret), "cassette contains %q", secret)
+	}
+
+	cassette, err = interserviceclient.NewCassette(
+		path,
+		interserviceclient.WithCassetteMode(interserviceclient.CassetteReplay),
+		interserviceclient.WithCassetteMatchers(
+			interserviceclient.MatchMethod,
+			interserviceclient.MatchPath,
+			interserviceclient.MatchBody,
+		),
+	)
+	assert.Nil(t, err)
+	client, err = interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "otp", RootDomain: srv.URL},
+		interserviceclient.WithTransport(cassette),
+	)
+	assert.Nil(t, err)
+
+	verified, err = interserviceclient.VerifyOTP(ctx, "+254711223344", "654321", client)
+	assert.Nil(t, err)
+	assert.True(t, verified)
+	assert.Equal(t, 1, hits)
+
+	_, err = interserviceclient.SendOTPHelper(ctx, "+254711223344", client)
+	assert.True(t, errors.Is(err, interserviceclient.ErrInteractionNotFound))
+}
+
+func TestCassette_ReplayMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+
+	_, err := interserviceclient.NewCassette(path, interserviceclient.WithCassetteMode(interserviceclient.CassetteReplay))
+	assert.NotNil(t, err)
+
+	cassette, err := interserviceclient.NewCassette(path)
+	assert.Nil(t, err)
+	assert.Empty(t, cassette.Interactions())
+
+	// nothing was recorded so nothing is written
+	assert.Nil(t, cassette.Save())
+	_, err = os.Stat(path)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestCassette_ResponseRedaction(t *testing.T) {
+	ctx := context.Background()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"otp":"123456","auth":{"id_token":"id-token","refresh_token":"refresh-token","uid":"test-uid"}}`))
+	}))
+	defer srv.Close()
+
+	record := func(path string, opts ...interserviceclient.CassetteOption) string {
+		opts = append(opts, interserviceclient.WithCassetteMode(interserviceclient.CassetteRecord))
+		cassette, err := interserviceclient.NewCassette(path, opts...)
+		assert.Nil(t, err)
+		client, err := interserviceclient.NewInterserviceClient(
+			interserviceclient.ISCService{Name: "onboarding", RootDomain: srv.URL},
+			interserviceclient.WithTransport(cassette),
+		)
+		assert.Nil(t, err)
+
+		payload := map[string]string{"phoneNumber": "+254711223344", "otp": "654321"}
+		got, err := interserviceclient.Do[map[string]interface{}](ctx, client, http.MethodPost, "testing/verify_phone", payload)
+		assert.Nil(t, err)
+		// the caller receives the response as it was sent
+		assert.Equal(t, "123456", got["otp"])
+		assert.Nil(t, cassette.Save())
+
+		data, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		return string(data)
+	}
+
+	// credentials are redacted from requests and responses by default
+	recording := record(filepath.Join(t.TempDir(), "redacted.json"))
+	for _, secret := range []string{"+254711223344", "654321", "123456", "id-token", "refresh-token"} {
+		assert.NotContains(t, recording, secret)
+	}
+	assert.Contains(t, recording, "test-uid")
+
+	// fields can be explicitly kept in responses
+	path := filepath.Join(t.TempDir(), "kept.json")
+	recording = record(path, interserviceclient.WithCassetteUnredactedResponseFields("otp"))
+	assert.Contains(t, recording, "123456")
+	assert.NotContains(t, recording, "654321")
+	assert.NotContains(t, recording, "id-token")
+
+	cassette, err := interserviceclient.NewCassette(path, interserviceclient.WithCassetteMode(interserviceclient.CassetteReplay))
+	assert.Nil(t, err)
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "onboarding", RootDomain: srv.URL},
+		interserviceclient.WithTransport(cassette),
+	)
+	assert.Nil(t, err)
+	got, err := interserviceclient.Do[map[string]interface{}](ctx, client, http.MethodPost, "testing/verify_phone", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "123456", got["otp"])
+}
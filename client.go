@@ -0,0 +1,70 @@
+package interserviceclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Client performs inter service requests to a single dependency. It is implemented by
+// InterServiceClient and by FakeClient, which can stand in for a dependency in unit tests.
+// The helpers in this package accept a Client rather than a concrete client
+type Client interface {
+	// DependencyName returns the name of the dependency that requests are sent to
+	DependencyName() string
+
+	// CreateAuthToken creates a token used to authenticate requests to the dependency
+	CreateAuthToken(ctx context.Context) (string, error)
+
+	// MakeRequest performs an inter service http request and returns the response
+	MakeRequest(ctx context.Context, method string, path string, body interface{}) (*http.Response, error)
+
+	// DoRequest performs an inter service http request customised by the supplied options
+	DoRequest(
+		ctx context.Context,
+		method string,
+		path string,
+		body interface{},
+		opts ...RequestOption,
+	) (*http.Response, error)
+
+	// MakeStreamRequest performs an inter service http request whose body is streamed from
+	// the supplied reader
+	MakeStreamRequest(
+		ctx context.Context,
+		method string,
+		path string,
+		contentType string,
+		body io.Reader,
+		opts ...RequestOption,
+	) (*http.Response, error)
+
+	// UploadFiles sends form fields and files as a multipart/form-data POST request
+	UploadFiles(
+		ctx context.Context,
+		path string,
+		fields map[string]string,
+		files []MultipartFile,
+		opts ...RequestOption,
+	) (*http.Response, error)
+}
+
+var (
+	_ Client = (*InterServiceClient)(nil)
+	_ Client = (*FakeClient)(nil)
+)
+
+// DependencyName returns the name of the dependency that requests are sent to
+func (c InterServiceClient) DependencyName() string {
+	return c.Name
+}
+
+// isNilClient checks for a missing client, including a nil *InterServiceClient stored in the
+// interface
+func isNilClient(client Client) bool {
+	if client == nil {
+		return true
+	}
+	c, ok := client.(*InterServiceClient)
+	return ok && c == nil
+}
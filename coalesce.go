@@ -0,0 +1,114 @@
+package interserviceclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// defaultCoalescingHeaders are the request headers that distinguish otherwise identical GET
+// requests when they are coalesced
+var defaultCoalescingHeaders = []string{"Accept", "Accept-Encoding", "Accept-Language"}
+
+// WithRequestCoalescing collapses concurrent identical GET requests into a single request to
+// the dependency, sharing its buffered response with every caller. Requests are identical when
+// they have the same URL and the same values for the Accept headers and the supplied headers,
+// and are made with the same credentials and per request headers. Requests made with the
+// client's own token are made under the same identity, while tokens from a token source and
+// headers set with WithHeader are specific to the caller
+func WithRequestCoalescing(headers ...string) ClientOption {
+	return func(o *clientOptions) {
+		o.coalesce = true
+		o.coalescingHeaders = append(append([]string{}, defaultCoalescingHeaders...), headers...)
+	}
+}
+
+// sharedResponse is a buffered response shared by coalesced requests
+type sharedResponse struct {
+	resp *http.Response
+	body []byte
+}
+
+// coalescingTransport sends one request for concurrent identical GET requests
+type coalescingTransport struct {
+	next    http.RoundTripper
+	headers []string
+	metrics *clientMetrics
+	group   singleflight.Group
+}
+
+// RoundTrip joins an identical request that is in flight or sends a new one
+func (t *coalescingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	ch := t.group.DoChan(t.key(req), func() (interface{}, error) {
+		return t.send(req)
+	})
+
+	select {
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	case result := <-ch:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		if result.Shared {
+			t.metrics.recordCoalesced(req.Context())
+		}
+		return result.Val.(*sharedResponse).response(req), nil
+	}
+}
+
+// key identifies identical requests made under the same identity
+func (t *coalescingTransport) key(req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(req.URL.String())
+	if identity := identityFromRequest(req); identity != "" {
+		fmt.Fprintf(&b, "\nidentity: %s", identity)
+	}
+	for _, name := range t.headers {
+		fmt.Fprintf(&b, "\n%s: %s", http.CanonicalHeaderKey(name), strings.Join(req.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// send makes the request and buffers the response. The request is not cancelled when the
+// caller that started it gives up, since other callers may be waiting for it, but it keeps
+// the caller's deadline
+func (t *coalescingTransport) send(req *http.Request) (*sharedResponse, error) {
+	ctx := context.WithoutCancel(req.Context())
+	if deadline, ok := req.Context().Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+	return &sharedResponse{resp: resp, body: body}, nil
+}
+
+// response returns a copy of the shared response for one of the callers
+func (s *sharedResponse) response(req *http.Request) *http.Response {
+	resp := *s.resp
+	resp.Header = s.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(s.body))
+	resp.ContentLength = int64(len(s.body))
+	resp.Request = req
+	return &resp
+}
@@ -0,0 +1,137 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterServiceClient_RequestCoalescing(t *testing.T) {
+	ctx := context.Background()
+
+	var hits int32
+	started := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.Method == http.MethodGet && r.URL.Path == "/users" {
+			started <- struct{}{}
+			// give the other callers time to join the request
+			time.Sleep(100 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte(`{"name": "Test"}`))
+	}))
+	defer srv.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithRequestCoalescing(),
+	)
+	assert.Nil(t, err)
+
+	type profile struct {
+		Name string `json:"name"`
+	}
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := interserviceclient.Do[profile](ctx, client, http.MethodGet, "users", nil)
+		first <- err
+	}()
+	<-started
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := interserviceclient.Do[profile](ctx, client, http.MethodGet, "users", nil)
+			assert.Nil(t, err)
+			assert.Equal(t, "Test", got.Name)
+		}()
+	}
+	wg.Wait()
+	assert.Nil(t, <-first)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	// other methods are never coalesced
+	assert.Nil(t, interserviceclient.Call(ctx, client, http.MethodPost, "users", nil))
+	assert.Nil(t, interserviceclient.Call(ctx, client, http.MethodPost, "users", nil))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+}
+
+func TestInterServiceClient_RequestCoalescingCancellation(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithRequestCoalescing(),
+	)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err = interserviceclient.Call(ctx, client, http.MethodGet, "users", nil)
+	assert.NotNil(t, err)
+}
+
+func TestInterServiceClient_RequestCoalescingIdentity(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		// give the other callers time to join the request
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"name": "` + r.Header.Get("Authorization") + r.Header.Get("X-Tenant") + `"}`))
+	}))
+	defer srv.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithRequestCoalescing(),
+		interserviceclient.WithTokenSource(interserviceclient.TokenSourceFunc(func(ctx context.Context) (string, error) {
+			return ctx.Value(userKey{}).(string), nil
+		})),
+	)
+	assert.Nil(t, err)
+
+	type profile struct {
+		Name string `json:"name"`
+	}
+	callers := []struct {
+		user string
+		opts []interserviceclient.RequestOption
+		want string
+	}{
+		{user: "alice", want: "Bearer alice"},
+		{user: "bob", want: "Bearer bob"},
+		{user: "alice", opts: []interserviceclient.RequestOption{interserviceclient.WithHeader("X-Tenant", "slade")}, want: "Bearer aliceslade"},
+	}
+
+	// requests made under different identities are never merged
+	var wg sync.WaitGroup
+	for _, caller := range callers {
+		wg.Add(1)
+		go func(user string, opts []interserviceclient.RequestOption, want string) {
+			defer wg.Done()
+			ctx := context.WithValue(context.Background(), userKey{}, user)
+			got, err := interserviceclient.Do[profile](ctx, client, http.MethodGet, "users", nil, opts...)
+			assert.Nil(t, err)
+			assert.Equal(t, want, got.Name)
+		}(caller.user, caller.opts, caller.want)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+}
@@ -11,10 +11,40 @@ const (
 	// AuthTokenContextKey is used to add/retrieve the Firebase UID on the context
 	AuthTokenContextKey = ContextKey("UID")
 
+	// RequestIDContextKey is used to add/retrieve the request ID on the context
+	RequestIDContextKey = ContextKey("requestID")
+
+	// RequestIDHeader carries the ID that correlates a user action across services
+	RequestIDHeader = "X-Request-ID"
+
+	// CorrelationIDHeader is an alternative request ID header accepted from callers
+	CorrelationIDHeader = "X-Correlation-ID"
+
+	// RequestTimeoutHeader carries the number of milliseconds the caller will wait for a response
+	RequestTimeoutHeader = "X-Request-Timeout"
+
+	// CallerServiceHeader carries the name of the service making an inter service request
+	CallerServiceHeader = "X-ISC-Caller"
+
 	// The file that contains dependency definition. Each service which depends on other service
 	// via REST, need to have this file in their root
 	DepsFileName = "deps.yaml"
 
+	// DepsFileEnv names the deps file to use instead of searching for one
+	DepsFileEnv = "ISC_DEPS_FILE"
+
+	// LocalDepsFileName is an optional file next to the deps file whose dependencies are merged
+	// on top of it e.g to point dependencies at local services during development
+	LocalDepsFileName = "deps.local.yaml"
+
+	// DepURLEnvPrefix starts the environment variables that override the root domain of a
+	// dependency e.g `ISC_DEP_PROFILE_URL` for the profile dependency
+	DepURLEnvPrefix = "ISC_DEP_"
+
+	// CassetteModeEnv overrides the mode of the cassettes used in tests e.g `record` to
+	// re-record them against the dependencies
+	CassetteModeEnv = "ISC_CASSETTE_MODE"
+
 	// running the service under e2e
 	E2eEnv = "e2e"
 
@@ -0,0 +1,66 @@
+package interserviceclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// remainingTime returns how long the caller will wait for a response, taking into account both
+// the context deadline and the client timeout
+func remainingTime(ctx context.Context, clientTimeout time.Duration) (time.Duration, bool) {
+	remaining := clientTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		untilDeadline := time.Until(deadline)
+		if remaining <= 0 || untilDeadline < remaining {
+			remaining = untilDeadline
+		}
+	}
+	return remaining, remaining > 0
+}
+
+// setTimeoutHeader tells the dependency how long the caller will wait for a response
+func setTimeoutHeader(req *http.Request, clientTimeout time.Duration) {
+	if remaining, ok := remainingTime(req.Context(), clientTimeout); ok {
+		req.Header.Set(RequestTimeoutHeader, strconv.FormatInt(remaining.Milliseconds(), 10))
+	}
+}
+
+// parseTimeoutHeader reads the time the caller will wait for a response from a request
+func parseTimeoutHeader(r *http.Request) (time.Duration, bool) {
+	value := r.Header.Get(RequestTimeoutHeader)
+	if value == "" {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ms <= 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// DeadlinePropagationMiddleware derives a deadline for the request context from the time the
+// caller is willing to wait for a response, so that work is abandoned once the caller has given up.
+// The derived timeout is capped at maxTimeout, when it is greater than zero. Requests without a
+// valid timeout header are only bounded by maxTimeout.
+func DeadlinePropagationMiddleware(maxTimeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				timeout, ok := parseTimeoutHeader(r)
+				if maxTimeout > 0 && (!ok || timeout > maxTimeout) {
+					timeout, ok = maxTimeout, true
+				}
+				if !ok {
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				ctx, cancel := context.WithTimeout(r.Context(), timeout)
+				defer cancel()
+
+				next.ServeHTTP(w, r.WithContext(ctx))
+			})
+	}
+}
@@ -0,0 +1,93 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterServiceClient_PropagatesDeadline(t *testing.T) {
+	var got string
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(interserviceclient.RequestTimeoutHeader)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err := interserviceclient.Call(ctx, client, http.MethodGet, "users", nil)
+	assert.Nil(t, err)
+
+	ms, err := strconv.Atoi(got)
+	assert.Nil(t, err)
+	assert.True(t, ms > 1000 && ms <= 2000, "unexpected timeout %d", ms)
+
+	// without a context deadline the client timeout applies
+	err = interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, strconv.Itoa(int(time.Minute.Milliseconds())), got)
+}
+
+func TestDeadlinePropagationMiddleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string
+		maxTimeout   time.Duration
+		wantDeadline bool
+		wantAtMost   time.Duration
+	}{
+		{
+			name:         "deadline derived from the caller's timeout",
+			header:       "2000",
+			wantDeadline: true,
+			wantAtMost:   2 * time.Second,
+		},
+		{
+			name:         "caller's timeout capped by local policy",
+			header:       "60000",
+			maxTimeout:   time.Second,
+			wantDeadline: true,
+			wantAtMost:   time.Second,
+		},
+		{
+			name:         "local policy applies without a timeout header",
+			maxTimeout:   time.Second,
+			wantDeadline: true,
+			wantAtMost:   time.Second,
+		},
+		{
+			name: "no deadline without a timeout header or local policy",
+		},
+		{
+			name:   "invalid timeout header is ignored",
+			header: "soon",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deadline time.Time
+			var hasDeadline bool
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				deadline, hasDeadline = r.Context().Deadline()
+			})
+			h := interserviceclient.DeadlinePropagationMiddleware(tt.maxTimeout)(next)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(interserviceclient.RequestTimeoutHeader, tt.header)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.wantDeadline, hasDeadline)
+			if tt.wantDeadline {
+				assert.True(t, time.Until(deadline) <= tt.wantAtMost)
+			}
+		})
+	}
+}
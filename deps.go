@@ -0,0 +1,374 @@
+package interserviceclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// envVarPattern matches `${VAR}` and `${VAR:-default}` references in a deps file
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// depNameEnvPattern matches the characters of a dependency name that can not be used in an
+// environment variable name
+var depNameEnvPattern = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// DepNotFoundError is returned when a dependency is not declared in the deps config
+type DepNotFoundError struct {
+	// The name of the dependency that was looked up
+	Name string
+}
+
+// Error implements the error interface
+func (e *DepNotFoundError) Error() string {
+	return fmt.Sprintf("dependency %s is not declared in the deps config", e.Name)
+}
+
+// DepsProblem is a single problem found while validating a deps config
+type DepsProblem struct {
+	// The environment the problem was found in
+	Environment string
+
+	// The name of the dependency with the problem, if any
+	Dependency string
+
+	// A description of the problem
+	Message string
+}
+
+// String describes the problem and where it was found
+func (p DepsProblem) String() string {
+	if p.Dependency == "" {
+		return fmt.Sprintf("%s: %s", p.Environment, p.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", p.Environment, p.Dependency, p.Message)
+}
+
+// DepsValidationError lists every problem found in a deps config
+type DepsValidationError struct {
+	Problems []DepsProblem
+}
+
+// Error implements the error interface
+func (e *DepsValidationError) Error() string {
+	problems := []string{}
+	for _, problem := range e.Problems {
+		problems = append(problems, problem.String())
+	}
+	return fmt.Sprintf("invalid deps config: %s", strings.Join(problems, "; "))
+}
+
+// Validate checks every environment of the config, returning a *DepsValidationError that lists
+// all the problems found. Dependencies must have a unique name within an environment and a valid
+// http or https root domain or endpoints
+func (c DepsConfig) Validate() error {
+	problems := []DepsProblem{}
+	for _, environment := range c.EnvironmentNames() {
+		problems = append(problems, validateDeps(environment, c.Environments[environment])...)
+	}
+	if len(problems) > 0 {
+		return &DepsValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateDeps checks the dependencies declared for an environment
+func validateDeps(environment string, deps []Dep) []DepsProblem {
+	problems := []DepsProblem{}
+	seen := map[string]bool{}
+	for i, dep := range deps {
+		problem := func(format string, args ...interface{}) {
+			problems = append(problems, DepsProblem{
+				Environment: environment,
+				Dependency:  dep.DepName,
+				Message:     fmt.Sprintf(format, args...),
+			})
+		}
+
+		if dep.DepName == "" {
+			problem("dependency %d has no name", i+1)
+		} else if seen[dep.DepName] {
+			problem("the dependency is declared more than once")
+		}
+		seen[dep.DepName] = true
+
+		if dep.DepRootDomain == "" && len(dep.DepEndpoints) == 0 {
+			problem("no root domain or endpoints are declared")
+		}
+		if dep.DepRootDomain != "" {
+			if err := validateRootDomain(dep.DepRootDomain); err != nil {
+				problem("invalid root domain: %v", err)
+			}
+		}
+		for _, endpoint := range dep.DepEndpoints {
+			if err := validateRootDomain(endpoint.URL); err != nil {
+				problem("invalid endpoint: %v", err)
+			}
+			if endpoint.Weight < 0 {
+				problem("invalid weight %d for endpoint %s", endpoint.Weight, endpoint.URL)
+			}
+		}
+
+		if dep.Timeout < 0 {
+			problem("invalid timeout %s", dep.Timeout)
+		}
+		if dep.Retry != nil && (dep.Retry.MaxAttempts < 0 || dep.Retry.InitialBackoff < 0 || dep.Retry.MaxBackoff < 0) {
+			problem("invalid retry policy, the attempts and backoffs can not be negative")
+		}
+		if dep.RateLimit != nil && (dep.RateLimit.RequestsPerSecond <= 0 || dep.RateLimit.Burst < 0) {
+			problem("invalid rate limit, the requests per second must be positive")
+		}
+		switch dep.AuthMode {
+		case "", AuthModeJWT, AuthModeNone:
+		default:
+			problem("unknown auth mode %q, use %q or %q", dep.AuthMode, AuthModeJWT, AuthModeNone)
+		}
+	}
+	return problems
+}
+
+// validateRootDomain checks that a root domain is an absolute http or https URL
+func validateRootDomain(rootDomain string) error {
+	u, err := url.Parse(rootDomain)
+	if err != nil {
+		return fmt.Errorf("%q is not a URL: %w", rootDomain, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%q must use http or https", rootDomain)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host", rootDomain)
+	}
+	return nil
+}
+
+// interpolate replaces the `${VAR}` and `${VAR:-default}` references in the values of a parsed
+// deps file with the values of the environment variables. The default is used when the
+// variable is unset or empty. References in comments and keys are left alone
+func interpolate(value interface{}) (interface{}, error) {
+	missing := []string{}
+	interpolated := interpolateValue(value, &missing)
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("the environment variables %s are not set and have no default", strings.Join(missing, ", "))
+	}
+	return interpolated, nil
+}
+
+// interpolateValue walks a decoded YAML value replacing the references in its string scalars.
+// A scalar that is a single reference takes the type of the value it is replaced with, as it
+// would have had the value been written in the file
+func interpolateValue(value interface{}, missing *[]string) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for key, nested := range v {
+			v[key] = interpolateValue(nested, missing)
+		}
+		return v
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = interpolateValue(nested, missing)
+		}
+		return v
+	case string:
+		if !envVarPattern.MatchString(v) {
+			return v
+		}
+		interpolated := envVarPattern.ReplaceAllStringFunc(v, func(ref string) string {
+			match := envVarPattern.FindStringSubmatch(ref)
+			if value := os.Getenv(match[1]); value != "" {
+				return value
+			}
+			if match[2] != "" {
+				return match[3]
+			}
+			*missing = append(*missing, match[1])
+			return ref
+		})
+		if envVarPattern.FindString(v) != v {
+			return interpolated
+		}
+		var scalar interface{}
+		if err := yaml.Unmarshal([]byte(interpolated), &scalar); err != nil {
+			return interpolated
+		}
+		switch scalar.(type) {
+		case int, float64, bool:
+			return scalar
+		}
+		return interpolated
+	default:
+		return v
+	}
+}
+
+// readDepsFile reads and interpolates a deps file
+func readDepsFile(source depsSource) (DepsConfig, error) {
+	var config DepsConfig
+
+	data, err := source.read()
+	if err != nil {
+		return config, fmt.Errorf("can't read deps file: %w", err)
+	}
+
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return config, fmt.Errorf("can't unmarshal deps YAML: %w", err)
+	}
+	raw, err = interpolate(raw)
+	if err != nil {
+		return config, fmt.Errorf("can't interpolate deps file %s: %w", source.path, err)
+	}
+
+	data, err = yaml.Marshal(raw)
+	if err != nil {
+		return config, fmt.Errorf("can't marshal interpolated deps YAML: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("can't unmarshal deps YAML: %w", err)
+	}
+	return config, nil
+}
+
+// loadDepsFile reads a deps file, merges the local deps file next to it if there is one and
+// applies the root domain overrides from the environment
+func loadDepsFile(source depsSource) (*DepsConfig, error) {
+	config, err := readDepsFile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := readDepsFile(source.local())
+	switch {
+	case err == nil:
+		config = config.Merge(local)
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+
+	config.applyEnvOverrides()
+	return &config, nil
+}
+
+// Merge returns a config with the dependencies of the overlay merged on top of those of the
+// config. The settings of a dependency declared in both replace those of the config when they
+// are set in the overlay, and new dependencies and environments are added
+func (c DepsConfig) Merge(overlay DepsConfig) DepsConfig {
+	merged := DepsConfig{Environments: map[string][]Dep{}}
+	for environment, deps := range c.Environments {
+		merged.Environments[environment] = append([]Dep{}, deps...)
+	}
+
+	for environment, overlayDeps := range overlay.Environments {
+		deps := merged.Environments[environment]
+	overlay:
+		for _, overlayDep := range overlayDeps {
+			for i, dep := range deps {
+				if dep.DepName == overlayDep.DepName {
+					deps[i] = dep.merge(overlayDep)
+					continue overlay
+				}
+			}
+			deps = append(deps, overlayDep)
+		}
+		merged.Environments[environment] = deps
+	}
+	return merged
+}
+
+// merge returns the dependency with the settings that are set in the overlay replaced
+func (d Dep) merge(overlay Dep) Dep {
+	if overlay.DepRootDomain != "" {
+		d.DepRootDomain = overlay.DepRootDomain
+		d.DepEndpoints = nil
+	}
+	if len(overlay.DepEndpoints) > 0 {
+		d.DepEndpoints = overlay.DepEndpoints
+	}
+	if overlay.Timeout != 0 {
+		d.Timeout = overlay.Timeout
+	}
+	if overlay.Retry != nil {
+		d.Retry = overlay.Retry
+	}
+	if overlay.RateLimit != nil {
+		d.RateLimit = overlay.RateLimit
+	}
+	if len(overlay.Headers) > 0 {
+		headers := map[string]string{}
+		for key, value := range d.Headers {
+			headers[key] = value
+		}
+		for key, value := range overlay.Headers {
+			headers[key] = value
+		}
+		d.Headers = headers
+	}
+	if overlay.HealthPath != "" {
+		d.HealthPath = overlay.HealthPath
+	}
+	if overlay.AuthMode != "" {
+		d.AuthMode = overlay.AuthMode
+	}
+	if overlay.Audience != "" {
+		d.Audience = overlay.Audience
+	}
+	return d
+}
+
+// DepURLEnvVar returns the environment variable that overrides the root domain of a dependency
+// e.g `ISC_DEP_PROFILE_URL` for `profile` and `ISC_DEP_SMS_GATEWAY_URL` for `sms-gateway`
+func DepURLEnvVar(name string) string {
+	name = strings.Trim(depNameEnvPattern.ReplaceAllString(strings.ToUpper(name), "_"), "_")
+	return fmt.Sprintf("%s%s_URL", DepURLEnvPrefix, name)
+}
+
+// applyEnvOverrides points the dependencies whose root domain is overridden in the environment
+// at the overriding URL in every environment, replacing their endpoints
+func (c DepsConfig) applyEnvOverrides() {
+	for _, deps := range c.Environments {
+		for i, dep := range deps {
+			if url := os.Getenv(DepURLEnvVar(dep.DepName)); url != "" {
+				deps[i].DepRootDomain = url
+				deps[i].DepEndpoints = nil
+			}
+		}
+	}
+}
+
+// WriteYAML writes the config as YAML e.g to print the effective config after interpolation,
+// local overrides and environment overrides are applied. The values of headers that usually
+// carry credentials are redacted
+func (c DepsConfig) WriteYAML(w io.Writer) error {
+	redactor := newRedactor(DefaultRedactedHeaders, nil)
+
+	printable := DepsConfig{Environments: map[string][]Dep{}}
+	for environment, deps := range c.Environments {
+		printableDeps := []Dep{}
+		for _, dep := range deps {
+			if len(dep.Headers) > 0 {
+				header := http.Header{}
+				for key, value := range dep.Headers {
+					header.Set(key, value)
+				}
+				dep.Headers = redactor.redactHeaders(header)
+			}
+			printableDeps = append(printableDeps, dep)
+		}
+		printable.Environments[environment] = printableDeps
+	}
+
+	data, err := yaml.Marshal(printable)
+	if err != nil {
+		return fmt.Errorf("can't marshal deps config: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
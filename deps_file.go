@@ -0,0 +1,171 @@
+package interserviceclient
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DepsFileNotFoundError is returned when the deps file can not be found
+type DepsFileNotFoundError struct {
+	// The locations that were checked for the deps file
+	Tried []string
+}
+
+// Error implements the error interface
+func (e *DepsFileNotFoundError) Error() string {
+	return fmt.Sprintf("can't find the deps file, tried %s", strings.Join(e.Tried, ", "))
+}
+
+// Is makes errors.Is(err, fs.ErrNotExist) true for a missing deps file
+func (e *DepsFileNotFoundError) Is(target error) bool {
+	return target == fs.ErrNotExist
+}
+
+// LoadDepsOption configures how the deps file is found by LoadDeps
+type LoadDepsOption func(*loadDepsOptions)
+
+// loadDepsOptions holds the settings used to find the deps file
+type loadDepsOptions struct {
+	path string
+	fsys fs.FS
+}
+
+// WithDepsFile loads the deps file at the path instead of searching for it
+func WithDepsFile(path string) LoadDepsOption {
+	return func(o *loadDepsOptions) {
+		o.path = path
+	}
+}
+
+// WithDepsFS loads the deps file at the path within the file system e.g a deps.yaml embedded
+// with `go:embed`. A deps.local.yaml next to it in the file system is merged on top
+func WithDepsFS(fsys fs.FS, path string) LoadDepsOption {
+	return func(o *loadDepsOptions) {
+		o.fsys = fsys
+		o.path = path
+	}
+}
+
+// depsSource is a deps file either on disk or within a file system
+type depsSource struct {
+	fsys fs.FS
+	path string
+}
+
+// read returns the contents of the deps file
+func (s depsSource) read() ([]byte, error) {
+	if s.fsys != nil {
+		return fs.ReadFile(s.fsys, s.path)
+	}
+	return os.ReadFile(filepath.Clean(s.path))
+}
+
+// modTime returns the time the deps file was last modified. It is zero when the file is missing
+func (s depsSource) modTime() time.Time {
+	var info fs.FileInfo
+	var err error
+	if s.fsys != nil {
+		info, err = fs.Stat(s.fsys, s.path)
+	} else {
+		info, err = os.Stat(s.path)
+	}
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// load reads the deps file and the local deps file next to it
+func (s depsSource) load() (*DepsConfig, error) {
+	config, err := loadDepsFile(s)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &DepsFileNotFoundError{Tried: []string{s.path}}
+	}
+	return config, err
+}
+
+// local returns the local deps file next to the deps file
+func (s depsSource) local() depsSource {
+	if s.fsys != nil {
+		return depsSource{fsys: s.fsys, path: path.Join(path.Dir(s.path), LocalDepsFileName)}
+	}
+	return depsSource{path: filepath.Join(filepath.Dir(s.path), LocalDepsFileName)}
+}
+
+// LoadDeps loads the interservice dependency config. The deps file is, in order of precedence:
+//   - the file set by WithDepsFile or WithDepsFS, whichever was applied last
+//   - the file named by the ISC_DEPS_FILE environment variable
+//   - the first deps.yaml found in the working directory or its parents, up to the module root
+//
+// The config is interpolated and overridden as described by LoadDepsFromYAML
+func LoadDeps(opts ...LoadDepsOption) (*DepsConfig, error) {
+	options := &loadDepsOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	source, err := options.source()
+	if err != nil {
+		return nil, err
+	}
+
+	return source.load()
+}
+
+// source returns the deps file to load following the precedence described by LoadDeps
+func (o *loadDepsOptions) source() (depsSource, error) {
+	switch {
+	case o.fsys != nil:
+		return depsSource{fsys: o.fsys, path: o.path}, nil
+	case o.path != "":
+		return depsSource{path: o.path}, nil
+	}
+
+	path, err := FindDepsFile()
+	if err != nil {
+		return depsSource{}, err
+	}
+	return depsSource{path: path}, nil
+}
+
+// FindDepsFile returns the path to the deps file named by the ISC_DEPS_FILE environment variable,
+// or else the first deps.yaml found in the working directory or its parents. The search stops at
+// the module root, the first directory with a go.mod file, or at the file system root
+func FindDepsFile() (string, error) {
+	if envPath := os.Getenv(DepsFileEnv); envPath != "" {
+		if _, err := os.Stat(envPath); err != nil {
+			return "", &DepsFileNotFoundError{Tried: []string{envPath}}
+		}
+		return envPath, nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("can't get the working directory to search for the deps file: %w", err)
+	}
+
+	tried := []string{}
+	for {
+		candidate := filepath.Join(dir, DepsFileName)
+		tried = append(tried, candidate)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", &DepsFileNotFoundError{Tried: tried}
+}
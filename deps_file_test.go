@@ -0,0 +1,98 @@
+package interserviceclient_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindDepsFile(t *testing.T) {
+	module := t.TempDir()
+	writeFile(t, filepath.Join(module, "go.mod"), "module example.com/service\n")
+	nested := filepath.Join(module, "pkg", "handlers")
+	assert.Nil(t, os.MkdirAll(nested, 0o750))
+	chdir(t, nested)
+
+	// the search stops at the module root
+	_, err := interserviceclient.FindDepsFile()
+	var notFound *interserviceclient.DepsFileNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	assert.Len(t, notFound.Tried, 3)
+	assert.Equal(t, filepath.Join(module, interserviceclient.DepsFileName), notFound.Tried[2])
+	assert.Empty(t, interserviceclient.PathToDepsFile())
+
+	writeFile(t, filepath.Join(module, interserviceclient.DepsFileName), "staging: []\n")
+	path, err := interserviceclient.FindDepsFile()
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(module, interserviceclient.DepsFileName), path)
+
+	explicit := filepath.Join(t.TempDir(), "service-deps.yaml")
+	t.Setenv(interserviceclient.DepsFileEnv, explicit)
+	_, err = interserviceclient.FindDepsFile()
+	assert.True(t, errors.As(err, &notFound))
+	assert.Equal(t, []string{explicit}, notFound.Tried)
+
+	writeFile(t, explicit, "sandbox: []\n")
+	path, err = interserviceclient.FindDepsFile()
+	assert.Nil(t, err)
+	assert.Equal(t, explicit, path)
+}
+
+func TestLoadDeps(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deps.yaml")
+	writeFile(t, path, `
+staging:
+  - depName: profile
+    depRootDomain: https://profile.staging.example.com
+`)
+
+	config, err := interserviceclient.LoadDeps(interserviceclient.WithDepsFile(path))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"staging"}, config.EnvironmentNames())
+
+	_, err = interserviceclient.LoadDeps(interserviceclient.WithDepsFile(filepath.Join(dir, "missing.yaml")))
+	var notFound *interserviceclient.DepsFileNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+}
+
+func TestLoadDeps_FS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/deps.yaml": &fstest.MapFile{Data: []byte(`
+staging:
+  - depName: profile
+    depRootDomain: https://profile.staging.example.com
+`)},
+		"config/deps.local.yaml": &fstest.MapFile{Data: []byte(`
+staging:
+  - depName: profile
+    depRootDomain: http://localhost:8080
+`)},
+	}
+
+	config, err := interserviceclient.LoadDeps(interserviceclient.WithDepsFS(fsys, "config/deps.yaml"))
+	assert.Nil(t, err)
+	deps, err := config.Deps("staging")
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:8080", deps[0].DepRootDomain)
+
+	// the embedded file takes precedence over the environment variable
+	path := filepath.Join(t.TempDir(), "deps.yaml")
+	writeFile(t, path, "sandbox: []\n")
+	t.Setenv(interserviceclient.DepsFileEnv, path)
+
+	config, err = interserviceclient.LoadDeps(interserviceclient.WithDepsFS(fsys, "config/deps.yaml"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"staging"}, config.EnvironmentNames())
+
+	config, err = interserviceclient.LoadDeps()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"sandbox"}, config.EnvironmentNames())
+}
@@ -0,0 +1,175 @@
+package interserviceclient_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDepsConfig_Validate(t *testing.T) {
+	config, err := interserviceclient.LoadDepsFromYAML()
+	assert.Nil(t, err)
+	assert.Nil(t, config.Validate())
+
+	invalid := interserviceclient.DepsConfig{Environments: map[string][]interserviceclient.Dep{
+		"staging": {
+			{DepName: "profile", DepRootDomain: "https://profile.example.com"},
+			{DepName: "profile", DepRootDomain: "https://profile-eu.example.com"},
+			{DepName: "engagement"},
+		},
+		"sandbox": {
+			{DepRootDomain: "https://nameless.example.com"},
+			{DepName: "mailgun", DepRootDomain: "ftp://mailgun.example.com"},
+			{DepName: "sms", DepEndpoints: []interserviceclient.Endpoint{{URL: "/sms", Weight: -1}}},
+		},
+	}}
+
+	err = invalid.Validate()
+	var validationErr *interserviceclient.DepsValidationError
+	assert.True(t, errors.As(err, &validationErr))
+
+	assert.Equal(t, []interserviceclient.DepsProblem{
+		{Environment: "sandbox", Message: "dependency 1 has no name"},
+		{Environment: "sandbox", Dependency: "mailgun", Message: `invalid root domain: "ftp://mailgun.example.com" must use http or https`},
+		{Environment: "sandbox", Dependency: "sms", Message: `invalid endpoint: "/sms" must use http or https`},
+		{Environment: "sandbox", Dependency: "sms", Message: "invalid weight -1 for endpoint /sms"},
+		{Environment: "staging", Dependency: "profile", Message: "the dependency is declared more than once"},
+		{Environment: "staging", Dependency: "engagement", Message: "no root domain or endpoints are declared"},
+	}, validationErr.Problems)
+	assert.Contains(t, err.Error(), "staging: engagement: no root domain or endpoints are declared")
+}
+
+// chdir changes the working directory for the duration of a test
+func chdir(t *testing.T, dir string) {
+	cwd, err := os.Getwd()
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chdir(dir))
+	t.Cleanup(func() {
+		_ = os.Chdir(cwd)
+	})
+}
+
+// writeFile writes a file in a test directory
+func writeFile(t *testing.T, path string, content string) {
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestLoadDepsFromYAML_Overrides(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+
+	writeFile(t, filepath.Join(dir, interserviceclient.DepsFileName), `
+staging:
+  - depName: profile
+    depRootDomain: ${PROFILE_URL:-https://profile.staging.example.com}
+    timeout: 10s
+    headers:
+      X-Tenant: bewell
+  - depName: engagement
+    depRootDomain: https://engagement.staging.example.com
+  - depName: sms-gateway
+    depRootDomain: https://sms.staging.example.com
+    depEndpoints:
+      - url: https://sms-eu.staging.example.com
+`)
+
+	config, err := interserviceclient.LoadDepsFromYAML()
+	assert.Nil(t, err)
+	deps, err := config.Deps("staging")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://profile.staging.example.com", deps[0].DepRootDomain)
+
+	t.Setenv("PROFILE_URL", "https://profile.example.com")
+	t.Setenv(interserviceclient.DepURLEnvVar("sms-gateway"), "http://localhost:9000")
+	writeFile(t, filepath.Join(dir, interserviceclient.LocalDepsFileName), `
+staging:
+  - depName: engagement
+    depRootDomain: http://localhost:8080
+    headers:
+      Authorization: Bearer local
+  - depName: mailgun
+    depRootDomain: http://localhost:8081
+sandbox:
+  - depName: profile
+    depRootDomain: http://localhost:8082
+`)
+
+	config, err = interserviceclient.LoadDepsFromYAML()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"sandbox", "staging"}, config.EnvironmentNames())
+
+	deps, err = config.Deps("staging")
+	assert.Nil(t, err)
+	assert.Len(t, deps, 4)
+
+	profile, err := interserviceclient.GetDepFromConfig("profile", deps)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://profile.example.com", profile.DepRootDomain)
+	assert.Equal(t, 10*time.Second, profile.Timeout)
+
+	engagement, err := interserviceclient.GetDepFromConfig("engagement", deps)
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:8080", engagement.DepRootDomain)
+
+	sms, err := interserviceclient.GetDepFromConfig("sms-gateway", deps)
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:9000", sms.DepRootDomain)
+	assert.Empty(t, sms.DepEndpoints)
+
+	var b bytes.Buffer
+	assert.Nil(t, config.WriteYAML(&b))
+	assert.Contains(t, b.String(), "depRootDomain: http://localhost:9000")
+	assert.Contains(t, b.String(), "timeout: 10s")
+	assert.Contains(t, b.String(), "X-Tenant: bewell")
+	assert.NotContains(t, b.String(), "Bearer local")
+}
+
+func TestLoadDepsFromYAML_MissingVariable(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+
+	writeFile(t, filepath.Join(dir, interserviceclient.DepsFileName), `
+staging:
+  - depName: profile
+    depRootDomain: ${ISC_TEST_UNSET_PROFILE_URL}
+`)
+
+	_, err := interserviceclient.LoadDepsFromYAML()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "ISC_TEST_UNSET_PROFILE_URL")
+}
+
+func TestLoadDepsFromYAML_InterpolatesValuesOnly(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	t.Setenv("ISC_TEST_RATE_LIMIT_BURST", "5")
+
+	writeFile(t, filepath.Join(dir, interserviceclient.DepsFileName), `
+# set ${ISC_TEST_UNSET_PROFILE_URL} to override the profile root domain
+staging:
+  - depName: profile
+    depRootDomain: https://profile.staging.example.com # or ${ISC_TEST_UNSET_PROFILE_URL}
+    rateLimit:
+      burst: ${ISC_TEST_RATE_LIMIT_BURST}
+      requestsPerSecond: ${ISC_TEST_UNSET_RPS:-2.5}
+`)
+
+	config, err := interserviceclient.LoadDepsFromYAML()
+	assert.Nil(t, err)
+	deps, err := config.Deps("staging")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://profile.staging.example.com", deps[0].DepRootDomain)
+	assert.Equal(t, 5, deps[0].RateLimit.Burst)
+	assert.Equal(t, 2.5, deps[0].RateLimit.RequestsPerSecond)
+}
+
+func TestDepURLEnvVar(t *testing.T) {
+	assert.Equal(t, "ISC_DEP_PROFILE_URL", interserviceclient.DepURLEnvVar("profile"))
+	assert.Equal(t, "ISC_DEP_SMS_GATEWAY_URL", interserviceclient.DepURLEnvVar("sms-gateway"))
+}
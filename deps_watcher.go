@@ -0,0 +1,303 @@
+package interserviceclient
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/savannahghi/serverutils"
+)
+
+// defaultDepsWatchInterval is how often a DepsWatcher checks the deps file for changes
+const defaultDepsWatchInterval = 10 * time.Second
+
+// DepChangeKind describes how a dependency changed between two versions of the deps config
+type DepChangeKind string
+
+const (
+	// DepAdded is a dependency that was declared in the new config
+	DepAdded DepChangeKind = "added"
+
+	// DepRemoved is a dependency that is no longer declared
+	DepRemoved DepChangeKind = "removed"
+
+	// DepUpdated is a dependency whose settings changed
+	DepUpdated DepChangeKind = "updated"
+)
+
+// DepChange is a change to a dependency between two versions of the deps config
+type DepChange struct {
+	Environment string
+	Dependency  string
+	Kind        DepChangeKind
+
+	// The dependency before and after the change. Old is nil for added dependencies and New is
+	// nil for removed ones
+	Old *Dep
+	New *Dep
+}
+
+// String describes the change e.g `staging: profile updated`
+func (c DepChange) String() string {
+	return fmt.Sprintf("%s: %s %s", c.Environment, c.Dependency, c.Kind)
+}
+
+// DepsEvent is emitted by a DepsWatcher each time it reloads the deps config
+type DepsEvent struct {
+	// The changes applied by the reload
+	Changes []DepChange
+
+	// Why the reload failed, in which case the previous config is still in use
+	Err error
+}
+
+// DepsWatcherOption configures a DepsWatcher
+type DepsWatcherOption func(*depsWatcherOptions)
+
+// depsWatcherOptions holds the settings used to create a DepsWatcher
+type depsWatcherOptions struct {
+	interval    time.Duration
+	environment string
+	loadOptions []LoadDepsOption
+	handler     func(DepsEvent)
+}
+
+// WithDepsWatchInterval sets how often the deps file is checked for changes. It must be
+// positive. The default is 10 seconds
+func WithDepsWatchInterval(interval time.Duration) DepsWatcherOption {
+	return func(o *depsWatcherOptions) {
+		o.interval = interval
+	}
+}
+
+// WithDepsWatchEnvironment sets the environment whose endpoints are resolved by the watcher.
+// The default is the environment the service is running in
+func WithDepsWatchEnvironment(environment string) DepsWatcherOption {
+	return func(o *depsWatcherOptions) {
+		o.environment = environment
+	}
+}
+
+// WithDepsWatchLoadOptions sets how the watched deps file is found
+func WithDepsWatchLoadOptions(opts ...LoadDepsOption) DepsWatcherOption {
+	return func(o *depsWatcherOptions) {
+		o.loadOptions = append(o.loadOptions, opts...)
+	}
+}
+
+// WithDepsChangeHandler sets a function that is called with the outcome of every reload that
+// is attempted after a change, e.g to log the changes. It is called from the watching goroutine
+func WithDepsChangeHandler(handler func(DepsEvent)) DepsWatcherOption {
+	return func(o *depsWatcherOptions) {
+		o.handler = handler
+	}
+}
+
+// DepsWatcher reloads the deps config when the deps file or the local deps file next to it
+// changes. A new config is only used when it is valid and declares the watched environment,
+// otherwise the previous config is kept.
+//
+// A DepsWatcher is a Resolver for the dependencies of the watched environment, so clients
+// created with WithDepsWatcher, e.g by SetupISCclient or NewRegistry, send their requests to
+// the latest endpoints. Other settings of a dependency take effect for clients created after
+// the change
+type DepsWatcher struct {
+	source      depsSource
+	environment string
+	handler     func(DepsEvent)
+
+	config   atomic.Pointer[DepsConfig]
+	resolver atomic.Pointer[StaticResolver]
+
+	mu       sync.Mutex
+	modTimes [2]time.Time
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDepsWatcher loads the deps config and watches it for changes until Close is called
+func NewDepsWatcher(opts ...DepsWatcherOption) (*DepsWatcher, error) {
+	options := &depsWatcherOptions{interval: defaultDepsWatchInterval}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.interval <= 0 {
+		return nil, fmt.Errorf("invalid deps watch interval %s", options.interval)
+	}
+	if options.environment == "" {
+		options.environment = serverutils.GetRunningEnvironment()
+	}
+
+	loadOptions := &loadDepsOptions{}
+	for _, opt := range options.loadOptions {
+		opt(loadOptions)
+	}
+	source, err := loadOptions.source()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &DepsWatcher{
+		source:      source,
+		environment: options.environment,
+		handler:     options.handler,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if _, err := w.Reload(); err != nil {
+		return nil, err
+	}
+
+	go w.watch(options.interval)
+	return w, nil
+}
+
+// WithDepsWatcher sends the client's requests to the endpoints of its dependency in the config
+// last loaded by the watcher, so that the client follows changes to the deps file e.g
+//
+//	client, err := SetupISCclient(*watcher.Config(), "profile", WithDepsWatcher(watcher))
+//
+// The watcher must watch the environment the client's dependency is declared for
+func WithDepsWatcher(watcher *DepsWatcher) ClientOption {
+	return WithResolver(watcher, 0)
+}
+
+// Config returns the deps config in use
+func (w *DepsWatcher) Config() *DepsConfig {
+	return w.config.Load()
+}
+
+// Resolve returns the endpoints of a dependency in the watched environment
+func (w *DepsWatcher) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	return w.resolver.Load().Resolve(ctx, service)
+}
+
+// Reload loads the deps config, using it when it is valid, and returns the changes it made.
+// The previous config is kept when the new one is invalid
+func (w *DepsWatcher) Reload() ([]DepChange, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// a config that fails to load is retried once the files change again
+	w.modTimes = [2]time.Time{w.source.modTime(), w.source.local().modTime()}
+
+	config, err := w.source.load()
+	if err == nil {
+		err = config.Validate()
+	}
+	var deps []Dep
+	if err == nil {
+		deps, err = config.Deps(w.environment)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't reload the deps config, keeping the previous config: %w", err)
+	}
+
+	var changes []DepChange
+	if previous := w.config.Load(); previous != nil {
+		changes = diffDeps(*previous, *config)
+	}
+
+	resolver := NewStaticResolver(deps)
+	w.config.Store(config)
+	w.resolver.Store(&resolver)
+	return changes, nil
+}
+
+// changed checks whether the deps files were modified since they were last loaded
+func (w *DepsWatcher) changed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return !w.source.modTime().Equal(w.modTimes[0]) || !w.source.local().modTime().Equal(w.modTimes[1])
+}
+
+// watch reloads the config whenever the deps files change
+func (w *DepsWatcher) watch(interval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if !w.changed() {
+			continue
+		}
+
+		changes, err := w.Reload()
+		if w.handler != nil {
+			w.handler(DepsEvent{Changes: changes, Err: err})
+		}
+	}
+}
+
+// Close stops watching the deps files
+func (w *DepsWatcher) Close() error {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+	return nil
+}
+
+// diffDeps returns the changes to the dependencies between two configs, sorted by environment
+// and dependency name
+func diffDeps(old DepsConfig, updated DepsConfig) []DepChange {
+	changes := []DepChange{}
+
+	environments := map[string]bool{}
+	for _, environment := range append(old.EnvironmentNames(), updated.EnvironmentNames()...) {
+		environments[environment] = true
+	}
+
+	for environment := range environments {
+		before := depsByName(old.Environments[environment])
+		after := depsByName(updated.Environments[environment])
+
+		for name, dep := range before {
+			dep := dep
+			newDep, ok := after[name]
+			switch {
+			case !ok:
+				changes = append(changes, DepChange{Environment: environment, Dependency: name, Kind: DepRemoved, Old: &dep})
+			case !reflect.DeepEqual(dep, newDep):
+				changes = append(changes, DepChange{Environment: environment, Dependency: name, Kind: DepUpdated, Old: &dep, New: &newDep})
+			}
+		}
+		for name, dep := range after {
+			dep := dep
+			if _, ok := before[name]; !ok {
+				changes = append(changes, DepChange{Environment: environment, Dependency: name, Kind: DepAdded, New: &dep})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Environment != changes[j].Environment {
+			return changes[i].Environment < changes[j].Environment
+		}
+		return changes[i].Dependency < changes[j].Dependency
+	})
+	return changes
+}
+
+// depsByName indexes dependencies by name
+func depsByName(deps []Dep) map[string]Dep {
+	indexed := map[string]Dep{}
+	for _, dep := range deps {
+		indexed[dep.DepName] = dep
+	}
+	return indexed
+}
@@ -0,0 +1,164 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDepsWatcher(t *testing.T) {
+	old := newEndpointServer(t, http.StatusOK)
+	moved := newEndpointServer(t, http.StatusOK)
+
+	path := filepath.Join(t.TempDir(), "deps.yaml")
+	start := time.Now().Add(-time.Hour)
+	write := func(content string, modTime time.Time) {
+		writeFile(t, path, content)
+		assert.Nil(t, os.Chtimes(path, modTime, modTime))
+	}
+	depsFile := func(profileURL string) string {
+		return fmt.Sprintf(`
+staging:
+  - depName: profile
+    depRootDomain: %s
+  - depName: engagement
+    depRootDomain: https://engagement.example.com
+`, profileURL)
+	}
+	write(depsFile(old.URL), start)
+
+	events := make(chan interserviceclient.DepsEvent, 10)
+	watcher, err := interserviceclient.NewDepsWatcher(
+		interserviceclient.WithDepsWatchLoadOptions(interserviceclient.WithDepsFile(path)),
+		interserviceclient.WithDepsWatchInterval(5*time.Millisecond),
+		interserviceclient.WithDepsChangeHandler(func(event interserviceclient.DepsEvent) {
+			events <- event
+		}),
+	)
+	assert.Nil(t, err)
+	defer watcher.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: old.URL},
+		interserviceclient.WithResolver(watcher, 0),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+
+	// the existing client follows the new root domain
+	write(depsFile(moved.URL), start.Add(time.Minute))
+	event := <-events
+	assert.Nil(t, event.Err)
+	assert.Len(t, event.Changes, 1)
+	assert.Equal(t, interserviceclient.DepUpdated, event.Changes[0].Kind)
+	assert.Equal(t, old.URL, event.Changes[0].Old.DepRootDomain)
+	assert.Equal(t, moved.URL, event.Changes[0].New.DepRootDomain)
+	assert.Equal(t, "staging: profile updated", event.Changes[0].String())
+
+	assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+	assert.Equal(t, 1, old.count())
+	assert.Equal(t, 1, moved.count())
+
+	// an invalid config is reported and the previous one is kept
+	write(`
+staging:
+  - depName: profile
+    depRootDomain: not-a-url
+`, start.Add(2*time.Minute))
+	event = <-events
+	assert.NotNil(t, event.Err)
+	assert.Empty(t, event.Changes)
+
+	deps, err := watcher.Config().Deps("staging")
+	assert.Nil(t, err)
+	assert.Len(t, deps, 2)
+	assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+	assert.Equal(t, 2, moved.count())
+
+	// the invalid config is not retried until it changes
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %v", event)
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	write(fmt.Sprintf(`
+staging:
+  - depName: profile
+    depRootDomain: %s
+  - depName: mailgun
+    depRootDomain: https://mailgun.example.com
+`, moved.URL), start.Add(3*time.Minute))
+	event = <-events
+	assert.Nil(t, event.Err)
+	assert.Equal(t, []string{"staging: engagement removed", "staging: mailgun added"}, []string{
+		event.Changes[0].String(), event.Changes[1].String(),
+	})
+}
+
+func TestDepsWatcher_SetupClients(t *testing.T) {
+	old := newEndpointServer(t, http.StatusOK)
+	moved := newEndpointServer(t, http.StatusOK)
+
+	path := filepath.Join(t.TempDir(), "deps.yaml")
+	depsFile := func(profileURL string) string {
+		return fmt.Sprintf("staging:\n  - depName: profile\n    depRootDomain: %s\n", profileURL)
+	}
+	writeFile(t, path, depsFile(old.URL))
+
+	watcher, err := interserviceclient.NewDepsWatcher(
+		interserviceclient.WithDepsWatchLoadOptions(interserviceclient.WithDepsFile(path)),
+	)
+	assert.Nil(t, err)
+	defer watcher.Close()
+
+	client, err := interserviceclient.SetupISCclient(*watcher.Config(), "profile", interserviceclient.WithDepsWatcher(watcher))
+	assert.Nil(t, err)
+
+	registry, err := interserviceclient.NewRegistry(*watcher.Config(), "", interserviceclient.WithDepsWatcher(watcher))
+	assert.Nil(t, err)
+	defer registry.Close()
+	registryClient, err := registry.Client("profile")
+	assert.Nil(t, err)
+
+	assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+	assert.Nil(t, interserviceclient.Call(context.Background(), registryClient, http.MethodGet, "users", nil))
+
+	// both clients follow the reloaded root domain
+	writeFile(t, path, depsFile(moved.URL))
+	changes, err := watcher.Reload()
+	assert.Nil(t, err)
+	assert.Len(t, changes, 1)
+
+	assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+	assert.Nil(t, interserviceclient.Call(context.Background(), registryClient, http.MethodGet, "users", nil))
+	assert.Equal(t, 2, old.count())
+	assert.Equal(t, 2, moved.count())
+}
+
+func TestNewDepsWatcher_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deps.yaml")
+	writeFile(t, path, "sandbox: []\n")
+
+	_, err := interserviceclient.NewDepsWatcher(
+		interserviceclient.WithDepsWatchLoadOptions(interserviceclient.WithDepsFile(path)),
+	)
+	assert.NotNil(t, err)
+
+	writeFile(t, path, "staging:\n  - depName: profile\n    depRootDomain: https://profile.example.com\n")
+	for _, interval := range []time.Duration{0, -time.Second} {
+		_, err = interserviceclient.NewDepsWatcher(
+			interserviceclient.WithDepsWatchEnvironment("staging"),
+			interserviceclient.WithDepsWatchInterval(interval),
+			interserviceclient.WithDepsWatchLoadOptions(interserviceclient.WithDepsFile(path)),
+		)
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,211 @@
+package interserviceclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/savannahghi/errorcodeutil"
+)
+
+// maxErrorBodyLength is the number of response body bytes included in an error message
+const maxErrorBodyLength = 1024
+
+// ISCError is returned when an inter service request fails, either because the dependency
+// could not be reached or because it responded with a status code the caller did not expect.
+// Use errors.As to retrieve it from a wrapped error.
+type ISCError struct {
+	// The name of the dependency that was called e.g profile, engagement
+	Service string
+
+	// The HTTP method of the failed request
+	Method string
+
+	// The path of the failed request on the dependency
+	Path string
+
+	// The status code returned by the dependency. It is zero when no response was received
+	StatusCode int
+
+	// The raw response body returned by the dependency
+	Body []byte
+
+	// The response body decoded from JSON, when the dependency returned a JSON object
+	ErrorBody map[string]interface{}
+
+	// The error message reported by the dependency, if any
+	Message string
+
+	// The error code reported by the dependency, if any. It is zero when absent
+	Code errorcodeutil.ErrorCode
+
+	// The underlying error when no response was received from the dependency
+	Err error
+}
+
+// Error implements the error interface
+func (e *ISCError) Error() string {
+	var b strings.Builder
+	if e.Service != "" {
+		fmt.Fprintf(&b, "%s: ", e.Service)
+	}
+	fmt.Fprintf(&b, "%s %s", e.Method, e.Path)
+
+	if e.Err != nil {
+		fmt.Fprintf(&b, " failed: %v", e.Err)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, " failed with status code %d", e.StatusCode)
+	if e.Code != 0 {
+		fmt.Fprintf(&b, " and error code %d", e.Code)
+	}
+
+	body := bytes.TrimSpace(e.Body)
+	if len(body) > maxErrorBodyLength {
+		body = append(body[:maxErrorBodyLength:maxErrorBodyLength], "..."...)
+	}
+	if len(body) > 0 {
+		fmt.Fprintf(&b, ": %s", body)
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying transport error, if any
+func (e *ISCError) Unwrap() error {
+	return e.Err
+}
+
+// newStatusError builds an *ISCError from an unexpected response and its already read body
+func newStatusError(service string, resp *http.Response, body []byte) *ISCError {
+	iscErr := &ISCError{
+		Service:    service,
+		StatusCode: resp.StatusCode,
+		Body:       body,
+	}
+	if resp.Request != nil {
+		iscErr.Method = resp.Request.Method
+		iscErr.Path = resp.Request.URL.Path
+	}
+
+	var errorBody map[string]interface{}
+	if err := json.Unmarshal(body, &errorBody); err == nil {
+		iscErr.ErrorBody = errorBody
+		iscErr.Message = errorMessage(errorBody)
+		iscErr.Code = errorCode(errorBody)
+	}
+
+	return iscErr
+}
+
+// errorMessage extracts the message from the error bodies written by our services
+// e.g `{"error": "..."}` or `{"message": "...", "code": 4}`
+func errorMessage(body map[string]interface{}) string {
+	for _, key := range []string{"message", "error"} {
+		if msg, ok := body[key].(string); ok && msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// errorCode extracts an errorcodeutil code from an error body
+func errorCode(body map[string]interface{}) errorcodeutil.ErrorCode {
+	code, ok := body["code"].(float64)
+	if !ok {
+		return 0
+	}
+	return errorcodeutil.ErrorCode(int(code))
+}
+
+// statusCode returns the status code carried by an *ISCError in the error chain, if any
+func statusCode(err error) int {
+	var iscErr *ISCError
+	if errors.As(err, &iscErr) {
+		return iscErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether the dependency responded with a 404 Not Found
+func IsNotFound(err error) bool {
+	return statusCode(err) == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether the dependency rejected the request with a 401 or 403
+func IsUnauthorized(err error) bool {
+	code := statusCode(err)
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+// IsRetryable reports whether a failed inter service request may succeed if retried.
+// Network failures and throttling or temporary unavailability of the dependency are retryable.
+// Failures to prepare the request, such as an invalid body or a failed token source, are not
+func IsRetryable(err error) bool {
+	var iscErr *ISCError
+	if !errors.As(err, &iscErr) {
+		return false
+	}
+	if iscErr.Err != nil {
+		return !errors.Is(iscErr.Err, context.Canceled) && isNetworkError(iscErr.Err)
+	}
+	switch iscErr.StatusCode {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isNetworkError checks whether an error was returned while sending a request or reading its
+// response, as opposed to while preparing the request
+func isNetworkError(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// returned by url.Parse for an invalid request url
+		return urlErr.Op != "parse"
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// ErrorCodeOf returns the errorcodeutil code reported by the dependency, if any
+func ErrorCodeOf(err error) (errorcodeutil.ErrorCode, bool) {
+	var iscErr *ISCError
+	if errors.As(err, &iscErr) && iscErr.Code != 0 {
+		return iscErr.Code, true
+	}
+	return 0, false
+}
+
+// transportError wraps an error that prevented a request to the named dependency from completing
+func transportError(service string, method string, path string, err error) *ISCError {
+	iscErr := &ISCError{
+		Service: service,
+		Method:  method,
+		Path:    path,
+		Err:     err,
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if u, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+			iscErr.Path = u.Path
+			return iscErr
+		}
+	}
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	iscErr.Path = "/" + strings.TrimPrefix(path, "/")
+	return iscErr
+}
@@ -0,0 +1,161 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/savannahghi/errorcodeutil"
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestISCError(t *testing.T) {
+	ctx := context.Background()
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"profile not found","code":7}`))
+	})
+
+	err := interserviceclient.Call(ctx, client, http.MethodGet, "profiles/1", nil)
+	wrapped := fmt.Errorf("can't fetch profile: %w", err)
+
+	var iscErr *interserviceclient.ISCError
+	if !errors.As(wrapped, &iscErr) {
+		t.Fatalf("expected an *ISCError, got %v", err)
+	}
+	assert.Equal(t, "test", iscErr.Service)
+	assert.Equal(t, http.MethodGet, iscErr.Method)
+	assert.Equal(t, "/profiles/1", iscErr.Path)
+	assert.Equal(t, http.StatusNotFound, iscErr.StatusCode)
+	assert.Equal(t, "profile not found", iscErr.Message)
+	assert.Equal(t, errorcodeutil.ProfileNotFound, iscErr.Code)
+	assert.Equal(t, "profile not found", iscErr.ErrorBody["message"])
+
+	assert.True(t, interserviceclient.IsNotFound(wrapped))
+	assert.False(t, interserviceclient.IsUnauthorized(wrapped))
+	assert.False(t, interserviceclient.IsRetryable(wrapped))
+
+	code, ok := interserviceclient.ErrorCodeOf(wrapped)
+	assert.True(t, ok)
+	assert.Equal(t, errorcodeutil.ProfileNotFound, code)
+}
+
+func TestISCError_Helpers(t *testing.T) {
+	tests := []struct {
+		name             string
+		err              error
+		wantNotFound     bool
+		wantUnauthorized bool
+		wantRetryable    bool
+	}{
+		{
+			name:             "unauthorized",
+			err:              &interserviceclient.ISCError{StatusCode: http.StatusUnauthorized},
+			wantUnauthorized: true,
+		},
+		{
+			name:             "forbidden",
+			err:              &interserviceclient.ISCError{StatusCode: http.StatusForbidden},
+			wantUnauthorized: true,
+		},
+		{
+			name:          "service unavailable",
+			err:           &interserviceclient.ISCError{StatusCode: http.StatusServiceUnavailable},
+			wantRetryable: true,
+		},
+		{
+			name:          "too many requests",
+			err:           &interserviceclient.ISCError{StatusCode: http.StatusTooManyRequests},
+			wantRetryable: true,
+		},
+		{
+			name:          "transport error",
+			err:           &interserviceclient.ISCError{Err: &net.OpError{Op: "dial", Err: fmt.Errorf("connection refused")}},
+			wantRetryable: true,
+		},
+		{
+			name: "request preparation error",
+			err:  &interserviceclient.ISCError{Err: fmt.Errorf("can't create token")},
+		},
+		{
+			name: "cancelled request",
+			err:  &interserviceclient.ISCError{Err: context.Canceled},
+		},
+		{
+			name: "bad request",
+			err:  &interserviceclient.ISCError{StatusCode: http.StatusBadRequest},
+		},
+		{
+			name: "not an ISC error",
+			err:  fmt.Errorf("some error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantNotFound, interserviceclient.IsNotFound(tt.err))
+			assert.Equal(t, tt.wantUnauthorized, interserviceclient.IsUnauthorized(tt.err))
+			assert.Equal(t, tt.wantRetryable, interserviceclient.IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestISCError_TransportError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(interserviceclient.ISCService{
+		Name:       "profile",
+		RootDomain: srv.URL,
+	})
+	assert.Nil(t, err)
+
+	err = interserviceclient.Call(context.Background(), client, http.MethodPost, "users", nil)
+
+	var iscErr *interserviceclient.ISCError
+	if !errors.As(err, &iscErr) {
+		t.Fatalf("expected an *ISCError, got %v", err)
+	}
+	assert.Equal(t, "profile", iscErr.Service)
+	assert.Equal(t, "/users", iscErr.Path)
+	assert.Zero(t, iscErr.StatusCode)
+	assert.NotNil(t, iscErr.Unwrap())
+	assert.True(t, interserviceclient.IsRetryable(err))
+}
+
+func TestISCError_NotRetryable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(interserviceclient.ISCService{
+		Name:       "profile",
+		RootDomain: srv.URL,
+	})
+	assert.Nil(t, err)
+
+	// the body can't be encoded
+	err = interserviceclient.Call(context.Background(), client, http.MethodPost, "users", make(chan int))
+	assert.NotNil(t, err)
+	assert.False(t, interserviceclient.IsRetryable(err))
+
+	// the request url is invalid
+	err = interserviceclient.Call(context.Background(), client, http.MethodGet, "users/%zz", nil)
+	assert.NotNil(t, err)
+	assert.False(t, interserviceclient.IsRetryable(err))
+
+	// the token can't be created
+	failing, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithTokenSource(interserviceclient.TokenSourceFunc(func(ctx context.Context) (string, error) {
+			return "", fmt.Errorf("token service unavailable")
+		})),
+	)
+	assert.Nil(t, err)
+	err = interserviceclient.Call(context.Background(), failing, http.MethodGet, "users", nil)
+	assert.NotNil(t, err)
+	assert.False(t, interserviceclient.IsRetryable(err))
+}
@@ -0,0 +1,279 @@
+package interserviceclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// FakeCall is a request recorded by a FakeClient
+type FakeCall struct {
+	Method string
+
+	// The request path without the query string or a leading slash e.g `internal/register_user`
+	Path string
+
+	Query  url.Values
+	Header http.Header
+
+	// The encoded request body, as it would have been sent to the dependency
+	Body []byte
+}
+
+// DecodeBody decodes the JSON body of the recorded request into v
+func (c FakeCall) DecodeBody(v interface{}) error {
+	return json.Unmarshal(c.Body, v)
+}
+
+// FakeHandler produces the response to a request made to a FakeClient
+type FakeHandler func(call FakeCall) (*http.Response, error)
+
+// FakeClient is a scriptable Client for unit tests. It records the requests made to it and
+// responds with the canned responses registered for each method and path.
+// Requests with no registered response get a 404 response
+type FakeClient struct {
+	// The name of the dependency being faked
+	Name string
+
+	mu       sync.Mutex
+	calls    []FakeCall
+	handlers map[string][]FakeHandler
+}
+
+// NewFakeClient creates a fake for the named dependency
+func NewFakeClient(name string) *FakeClient {
+	return &FakeClient{
+		Name:     name,
+		handlers: map[string][]FakeHandler{},
+	}
+}
+
+// fakeRoute identifies the responses registered for a method and path
+func fakeRoute(method string, path string) string {
+	return fmt.Sprintf("%s %s", method, strings.TrimPrefix(path, "/"))
+}
+
+// Handle registers a handler for requests with the method and path. Handlers registered for
+// the same method and path are used in turn, with the last one answering any further requests
+func (f *FakeClient) Handle(method string, path string, handler FakeHandler) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.handlers == nil {
+		f.handlers = map[string][]FakeHandler{}
+	}
+	route := fakeRoute(method, path)
+	f.handlers[route] = append(f.handlers[route], handler)
+}
+
+// Respond registers a response with the status code and the body encoded as JSON
+func (f *FakeClient) Respond(method string, path string, statusCode int, body interface{}) {
+	f.Handle(method, path, func(FakeCall) (*http.Response, error) {
+		return NewJSONResponse(statusCode, body)
+	})
+}
+
+// RespondError registers an error for requests that fail to reach the dependency
+func (f *FakeClient) RespondError(method string, path string, err error) {
+	f.Handle(method, path, func(FakeCall) (*http.Response, error) {
+		return nil, err
+	})
+}
+
+// Calls returns the requests made so far
+func (f *FakeClient) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]FakeCall{}, f.calls...)
+}
+
+// CallsTo returns the requests made so far with the method and path
+func (f *FakeClient) CallsTo(method string, path string) []FakeCall {
+	route := fakeRoute(method, path)
+
+	calls := []FakeCall{}
+	for _, call := range f.Calls() {
+		if fakeRoute(call.Method, call.Path) == route {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset clears the recorded requests and registered responses
+func (f *FakeClient) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = nil
+	f.handlers = map[string][]FakeHandler{}
+}
+
+// DependencyName returns the name of the dependency being faked
+func (f *FakeClient) DependencyName() string {
+	return f.Name
+}
+
+// CreateAuthToken returns a placeholder token
+func (f *FakeClient) CreateAuthToken(ctx context.Context) (string, error) {
+	return "fake-token", nil
+}
+
+// MakeRequest records the request and returns the registered response
+func (f *FakeClient) MakeRequest(ctx context.Context, method string, path string, body interface{}) (*http.Response, error) {
+	return f.DoRequest(ctx, method, path, body)
+}
+
+// DoRequest records the request and returns the registered response. Request options that
+// change the request e.g the query, headers and body are applied to the recorded request
+func (f *FakeClient) DoRequest(
+	ctx context.Context,
+	method string,
+	path string,
+	body interface{},
+	opts ...RequestOption,
+) (*http.Response, error) {
+	options := newRequestOptions(opts)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse request path %s: %w", path, err)
+	}
+
+	query := u.Query()
+	for key, values := range options.query {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+
+	var payload []byte
+	switch {
+	case options.bodyReader != nil:
+		payload, err = io.ReadAll(options.bodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read request body: %w", err)
+		}
+	case method != http.MethodGet:
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal the body to JSON: %w", err)
+		}
+	}
+
+	header := options.headers.Clone()
+	if payload != nil {
+		header.Set("Content-Type", options.contentType)
+	}
+
+	call := FakeCall{
+		Method: method,
+		Path:   strings.TrimPrefix(u.Path, "/"),
+		Query:  query,
+		Header: header,
+		Body:   payload,
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	handler := f.nextHandler(fakeRoute(method, call.Path))
+	f.mu.Unlock()
+
+	resp, err := handler(call)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("fake handler for %s returned no response", fakeRoute(method, call.Path))
+	}
+
+	requestURL := &url.URL{
+		Scheme:   "http",
+		Host:     f.Name,
+		Path:     "/" + call.Path,
+		RawQuery: query.Encode(),
+	}
+	resp.Request = &http.Request{Method: method, URL: requestURL, Header: header}
+	return resp, nil
+}
+
+// nextHandler returns the handler for the next request to the route. The caller must hold the lock
+func (f *FakeClient) nextHandler(route string) FakeHandler {
+	handlers := f.handlers[route]
+	if len(handlers) == 0 {
+		return func(FakeCall) (*http.Response, error) {
+			return NewJSONResponse(http.StatusNotFound, map[string]string{
+				"error": fmt.Sprintf("no fake response registered for %s", route),
+			})
+		}
+	}
+	if len(handlers) > 1 {
+		f.handlers[route] = handlers[1:]
+	}
+	return handlers[0]
+}
+
+// MakeStreamRequest records the request, reading the whole body, and returns the registered response
+func (f *FakeClient) MakeStreamRequest(
+	ctx context.Context,
+	method string,
+	path string,
+	contentType string,
+	body io.Reader,
+	opts ...RequestOption,
+) (*http.Response, error) {
+	opts = append(opts, WithBodyReader(body, contentType))
+	return f.DoRequest(ctx, method, path, nil, opts...)
+}
+
+// UploadFiles records the multipart/form-data request and returns the registered response
+func (f *FakeClient) UploadFiles(
+	ctx context.Context,
+	path string,
+	fields map[string]string,
+	files []MultipartFile,
+	opts ...RequestOption,
+) (*http.Response, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	if err := writeMultipart(mw, fields, files); err != nil {
+		return nil, err
+	}
+	return f.MakeStreamRequest(ctx, http.MethodPost, path, mw.FormDataContentType(), &buf, opts...)
+}
+
+// NewJSONResponse creates a response with the status code and the body encoded as JSON, for
+// use in FakeClient handlers. A nil body results in an empty response body
+func NewJSONResponse(statusCode int, body interface{}) (*http.Response, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal the body to JSON: %w", err)
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+	}, nil
+}
@@ -0,0 +1,99 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/savannahghi/profileutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClient_RecordsCalls(t *testing.T) {
+	ctx := context.Background()
+	fake := interserviceclient.NewFakeClient("onboarding")
+	fake.Respond(http.MethodPost, "internal/register_user", http.StatusOK, profileutils.UserProfile{ID: "123"})
+
+	onboarding := interserviceclient.NewOnboardingService(fake)
+	profile, err := onboarding.RegisterUser(ctx, map[string]string{"firstName": "Test"})
+	assert.Nil(t, err)
+	assert.Equal(t, "123", profile.ID)
+
+	calls := fake.CallsTo(http.MethodPost, "/internal/register_user")
+	assert.Len(t, calls, 1)
+	assert.Equal(t, "application/json", calls[0].Header.Get("Content-Type"))
+
+	var payload map[string]string
+	assert.Nil(t, calls[0].DecodeBody(&payload))
+	assert.Equal(t, "Test", payload["firstName"])
+}
+
+func TestFakeClient_Responses(t *testing.T) {
+	ctx := context.Background()
+	fake := interserviceclient.NewFakeClient("profile")
+	fake.Respond(http.MethodGet, "users", http.StatusServiceUnavailable, map[string]string{"error": "down"})
+	fake.Respond(http.MethodGet, "users", http.StatusOK, []string{"a"})
+
+	_, err := interserviceclient.Do[[]string](ctx, fake, http.MethodGet, "users", nil)
+	assert.True(t, interserviceclient.IsRetryable(err))
+
+	// the last response answers any further requests
+	for i := 0; i < 2; i++ {
+		got, err := interserviceclient.Do[[]string](
+			ctx, fake, http.MethodGet, "users?page=2", nil,
+			interserviceclient.WithQueryParam("size", "10"),
+		)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a"}, got)
+	}
+
+	calls := fake.Calls()
+	assert.Len(t, calls, 3)
+	assert.Equal(t, "2", calls[2].Query.Get("page"))
+	assert.Equal(t, "10", calls[2].Query.Get("size"))
+
+	err = interserviceclient.Call(ctx, fake, http.MethodDelete, "users/1", nil)
+	assert.True(t, interserviceclient.IsNotFound(err))
+
+	offline := errors.New("connection refused")
+	fake.RespondError(http.MethodPost, "internal/send_otp/", offline)
+	_, err = interserviceclient.SendOTPHelper(ctx, "+254711223344", fake)
+	assert.True(t, errors.Is(err, offline))
+
+	var iscErr *interserviceclient.ISCError
+	assert.True(t, errors.As(err, &iscErr))
+	assert.Equal(t, "/internal/send_otp/", iscErr.Path)
+
+	fake.Reset()
+	assert.Empty(t, fake.Calls())
+}
+
+func TestSendSMS_Fake(t *testing.T) {
+	ctx := context.Background()
+	sms := interserviceclient.NewFakeClient("sms")
+	twilio := interserviceclient.NewFakeClient("twilio")
+	sms.Respond(http.MethodPost, "internal/send_sms", http.StatusOK, nil)
+	twilio.Respond(http.MethodPost, "internal/send_sms", http.StatusOK, nil)
+
+	err := interserviceclient.SendSMS(
+		ctx,
+		[]string{"+254711223344", "+12025550123"},
+		"hello",
+		interserviceclient.SmsISC{Isc: sms, EndPoint: "internal/send_sms"},
+		interserviceclient.SmsISC{Isc: twilio, EndPoint: "internal/send_sms"},
+	)
+	assert.Nil(t, err)
+	assert.Len(t, sms.Calls(), 1)
+	assert.Len(t, twilio.Calls(), 1)
+
+	err = interserviceclient.SendSMS(
+		ctx,
+		[]string{"+254711223344"},
+		"hello",
+		interserviceclient.SmsISC{EndPoint: "internal/send_sms"},
+		interserviceclient.SmsISC{},
+	)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,156 @@
+package interserviceclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GraphQLRequest is a request to the GraphQL endpoint of a dependency
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// GraphQLLocation is a location in a GraphQL document
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLError is an error reported in the `errors` array of a GraphQL response
+type GraphQLError struct {
+	Message string `json:"message"`
+
+	// The path of the response field that failed, made up of field names and list indices
+	Path []interface{} `json:"path,omitempty"`
+
+	Locations  []GraphQLLocation      `json:"locations,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Error describes the error and the field it relates to
+func (e GraphQLError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, segment := range e.Path {
+		path[i] = fmt.Sprint(segment)
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(path, "."), e.Message)
+}
+
+// Code returns the `code` extension of the error, if any
+func (e GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// GraphQLErrors is returned when a GraphQL response has errors, whatever its status code.
+// Use errors.As to inspect the individual errors
+type GraphQLErrors struct {
+	Service       string
+	OperationName string
+	StatusCode    int
+	Errors        []GraphQLError
+
+	// The *ISCError for responses whose status code was not a success
+	Err error
+}
+
+// Error lists the GraphQL errors
+func (e *GraphQLErrors) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+
+	operation := e.OperationName
+	if operation == "" {
+		operation = "GraphQL request"
+	}
+	return fmt.Sprintf("%s to %s failed: %s", operation, e.Service, strings.Join(messages, "; "))
+}
+
+// Unwrap returns the status error, if any
+func (e *GraphQLErrors) Unwrap() error {
+	return e.Err
+}
+
+// graphQLResponse is the body of a GraphQL response
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []GraphQLError  `json:"errors"`
+}
+
+// GraphQL posts a query to the GraphQL endpoint at path and decodes the `data` of the response
+// into Data. Errors in the response are returned as *GraphQLErrors, together with any partial
+// data, even when the status code is 200
+func GraphQL[Data any](
+	ctx context.Context,
+	client Client,
+	path string,
+	request GraphQLRequest,
+	opts ...RequestOption,
+) (Data, error) {
+	var result Data
+	if isNilClient(client) {
+		return result, fmt.Errorf("nil ISC client")
+	}
+	if request.Query == "" {
+		return result, fmt.Errorf("a GraphQL query must be supplied")
+	}
+
+	if request.OperationName != "" {
+		opts = append([]RequestOption{WithOperationName(request.OperationName)}, opts...)
+	}
+
+	resp, err := client.DoRequest(ctx, http.MethodPost, path, request, opts...)
+	if err != nil {
+		return result, transportError(client.DependencyName(), http.MethodPost, path, err)
+	}
+
+	data, statusErr := readResponse(client.DependencyName(), resp, newRequestOptions(opts).expectedStatus...)
+
+	var body graphQLResponse
+	var iscErr *ISCError
+	if errors.As(statusErr, &iscErr) {
+		data = iscErr.Body
+	} else if statusErr != nil {
+		return result, statusErr
+	}
+
+	if err := json.Unmarshal(data, &body); err != nil {
+		if statusErr != nil {
+			return result, statusErr
+		}
+		return result, fmt.Errorf("can't unmarshal GraphQL response from JSON: %w", err)
+	}
+
+	if len(body.Data) > 0 && !bytes.Equal(bytes.TrimSpace(body.Data), []byte("null")) {
+		if err := json.Unmarshal(body.Data, &result); err != nil {
+			return result, fmt.Errorf("can't unmarshal GraphQL data from JSON: %w", err)
+		}
+	}
+
+	if len(body.Errors) > 0 {
+		return result, &GraphQLErrors{
+			Service:       client.DependencyName(),
+			OperationName: request.OperationName,
+			StatusCode:    resp.StatusCode,
+			Errors:        body.Errors,
+			Err:           statusErr,
+		}
+	}
+	if statusErr != nil {
+		return result, statusErr
+	}
+
+	return result, nil
+}
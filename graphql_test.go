@@ -0,0 +1,102 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphQL(t *testing.T) {
+	ctx := context.Background()
+
+	type user struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	type data struct {
+		User *user `json:"user"`
+	}
+
+	query := interserviceclient.GraphQLRequest{
+		Query:         `query user($id: ID!) { user(id: $id) { id name } }`,
+		Variables:     map[string]interface{}{"id": "1"},
+		OperationName: "user",
+	}
+
+	t.Run("data", func(t *testing.T) {
+		fake := interserviceclient.NewFakeClient("profile")
+		fake.Respond(http.MethodPost, "graphql", http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"user": map[string]string{"id": "1", "name": "Test"}},
+		})
+
+		got, err := interserviceclient.GraphQL[data](ctx, fake, "graphql", query)
+		assert.Nil(t, err)
+		assert.Equal(t, "Test", got.User.Name)
+
+		var sent interserviceclient.GraphQLRequest
+		assert.Nil(t, fake.Calls()[0].DecodeBody(&sent))
+		assert.Equal(t, query, sent)
+	})
+
+	t.Run("errors with a 200 status", func(t *testing.T) {
+		fake := interserviceclient.NewFakeClient("profile")
+		fake.Respond(http.MethodPost, "graphql", http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"user": nil},
+			"errors": []map[string]interface{}{{
+				"message":    "user not found",
+				"path":       []interface{}{"user"},
+				"locations":  []map[string]int{{"line": 1, "column": 28}},
+				"extensions": map[string]string{"code": "NOT_FOUND"},
+			}},
+		})
+
+		got, err := interserviceclient.GraphQL[data](ctx, fake, "graphql", query)
+		assert.Nil(t, got.User)
+
+		var gqlErrs *interserviceclient.GraphQLErrors
+		if !errors.As(err, &gqlErrs) {
+			t.Fatalf("expected *GraphQLErrors, got %v", err)
+		}
+		assert.Equal(t, "user to profile failed: user: user not found", err.Error())
+		assert.Equal(t, http.StatusOK, gqlErrs.StatusCode)
+		assert.Len(t, gqlErrs.Errors, 1)
+		assert.Equal(t, "NOT_FOUND", gqlErrs.Errors[0].Code())
+		assert.Equal(t, 28, gqlErrs.Errors[0].Locations[0].Column)
+		assert.Nil(t, gqlErrs.Unwrap())
+	})
+
+	t.Run("errors with a failed status", func(t *testing.T) {
+		fake := interserviceclient.NewFakeClient("profile")
+		fake.Respond(http.MethodPost, "graphql", http.StatusBadRequest, map[string]interface{}{
+			"errors": []map[string]interface{}{{"message": "syntax error"}},
+		})
+
+		_, err := interserviceclient.GraphQL[data](ctx, fake, "graphql", query)
+
+		var gqlErrs *interserviceclient.GraphQLErrors
+		assert.True(t, errors.As(err, &gqlErrs))
+
+		var iscErr *interserviceclient.ISCError
+		assert.True(t, errors.As(err, &iscErr))
+		assert.Equal(t, http.StatusBadRequest, iscErr.StatusCode)
+	})
+
+	t.Run("failed status without errors", func(t *testing.T) {
+		fake := interserviceclient.NewFakeClient("profile")
+		fake.Respond(http.MethodPost, "graphql", http.StatusServiceUnavailable, nil)
+
+		_, err := interserviceclient.GraphQL[data](ctx, fake, "graphql", query)
+		assert.True(t, interserviceclient.IsRetryable(err))
+	})
+
+	t.Run("missing query", func(t *testing.T) {
+		fake := interserviceclient.NewFakeClient("profile")
+		_, err := interserviceclient.GraphQL[data](ctx, fake, "graphql", interserviceclient.GraphQLRequest{})
+		assert.NotNil(t, err)
+		assert.Empty(t, fake.Calls())
+	})
+}
@@ -0,0 +1,355 @@
+package interserviceclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/savannahghi/serverutils"
+)
+
+const (
+	// defaultHealthPath is the path probed on each dependency
+	defaultHealthPath = "health"
+
+	// defaultHealthInterval is the time between health checks
+	defaultHealthInterval = 30 * time.Second
+
+	// defaultHealthTimeout is the time limit for a single probe
+	defaultHealthTimeout = 5 * time.Second
+)
+
+// DependencyStatus is the outcome of the latest health check of a dependency
+type DependencyStatus struct {
+	Name       string    `json:"name"`
+	URL        string    `json:"url"`
+	Healthy    bool      `json:"healthy"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	LatencyMs  int64     `json:"latencyMs"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+// HealthReport is the aggregated health of the dependencies of a service
+type HealthReport struct {
+	// Whether every dependency passed its latest health check
+	Healthy      bool               `json:"healthy"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// HealthCheckOption configures a HealthChecker
+type HealthCheckOption func(*healthCheckOptions)
+
+// healthCheckOptions holds the settings used to create a HealthChecker
+type healthCheckOptions struct {
+	path          string
+	interval      time.Duration
+	timeout       time.Duration
+	environment   string
+	clientOptions []ClientOption
+	details       bool
+}
+
+// WithHealthPath sets the path probed on each dependency. The default is `health`
+func WithHealthPath(path string) HealthCheckOption {
+	return func(o *healthCheckOptions) {
+		o.path = path
+	}
+}
+
+// WithHealthInterval sets the time between health checks. It must be positive. The default
+// is 30 seconds
+func WithHealthInterval(interval time.Duration) HealthCheckOption {
+	return func(o *healthCheckOptions) {
+		o.interval = interval
+	}
+}
+
+// WithHealthTimeout sets the time limit for probing a dependency. The default is 5 seconds
+func WithHealthTimeout(timeout time.Duration) HealthCheckOption {
+	return func(o *healthCheckOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithHealthEnvironment sets the environment whose dependencies are checked.
+// The default is the environment the service is running in
+func WithHealthEnvironment(environment string) HealthCheckOption {
+	return func(o *healthCheckOptions) {
+		o.environment = environment
+	}
+}
+
+// WithHealthClientOptions sets the options used to create the clients that probe the dependencies
+func WithHealthClientOptions(opts ...ClientOption) HealthCheckOption {
+	return func(o *healthCheckOptions) {
+		o.clientOptions = append(o.clientOptions, opts...)
+	}
+}
+
+// WithHealthDetails makes the health handler serve the URL, error, latency and check time of
+// each dependency. They are left out by default since they expose the internal topology of
+// the service, and DNS or TLS details, to anyone who can reach the probe
+func WithHealthDetails() HealthCheckOption {
+	return func(o *healthCheckOptions) {
+		o.details = true
+	}
+}
+
+// healthTarget is a dependency probed by a HealthChecker
+type healthTarget struct {
+	dep    Dep
+	client Client
+}
+
+// HealthChecker periodically probes the health path of the dependencies declared for an
+// environment using inter service authentication
+type HealthChecker struct {
+	targets  []healthTarget
+	path     string
+	interval time.Duration
+	timeout  time.Duration
+	details  bool
+
+	mu       sync.RWMutex
+	statuses map[string]DependencyStatus
+	stop     context.CancelFunc
+	done     chan struct{}
+}
+
+// NewHealthChecker creates a checker for the dependencies declared for the current environment
+func NewHealthChecker(config DepsConfig, opts ...HealthCheckOption) (*HealthChecker, error) {
+	options := &healthCheckOptions{
+		path:     defaultHealthPath,
+		interval: defaultHealthInterval,
+		timeout:  defaultHealthTimeout,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.interval <= 0 {
+		return nil, fmt.Errorf("invalid health check interval %s", options.interval)
+	}
+	if options.environment == "" {
+		options.environment = serverutils.GetRunningEnvironment()
+	}
+
+	deps, err := config.Deps(options.environment)
+	if err != nil {
+		return nil, err
+	}
+	if problems := validateDeps(options.environment, deps); len(problems) > 0 {
+		return nil, &DepsValidationError{Problems: problems}
+	}
+
+	checker := &HealthChecker{
+		path:     options.path,
+		interval: options.interval,
+		timeout:  options.timeout,
+		details:  options.details,
+		statuses: map[string]DependencyStatus{},
+	}
+	for _, dep := range deps {
+		client, err := NewInterserviceClient(
+			ISCService{Name: dep.DepName, RootDomain: dep.DepRootDomain, Endpoints: dep.DepEndpoints},
+			append(append([]ClientOption{}, options.clientOptions...), dep.ClientOptions()...)...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("can't create a health check client for %s: %w", dep.DepName, err)
+		}
+		checker.targets = append(checker.targets, healthTarget{dep: dep, client: client})
+	}
+
+	return checker, nil
+}
+
+// Check probes every dependency and returns the resulting report. Probes cut short by the
+// context being done are not recorded
+func (h *HealthChecker) Check(ctx context.Context) HealthReport {
+	var wg sync.WaitGroup
+	for _, target := range h.targets {
+		wg.Add(1)
+		go func(target healthTarget) {
+			defer wg.Done()
+			status := h.probe(ctx, target)
+			if ctx.Err() != nil {
+				// the check was abandoned, e.g by Stop, rather than failed by the dependency
+				return
+			}
+
+			h.mu.Lock()
+			h.statuses[target.dep.DepName] = status
+			h.mu.Unlock()
+		}(target)
+	}
+	wg.Wait()
+
+	return h.Status()
+}
+
+// probe checks the health of a single dependency
+func (h *HealthChecker) probe(ctx context.Context, target healthTarget) DependencyStatus {
+	status := DependencyStatus{
+		Name:      target.dep.DepName,
+		URL:       target.dep.DepRootDomain,
+		CheckedAt: time.Now(),
+	}
+
+	path := h.path
+	if target.dep.HealthPath != "" {
+		path = target.dep.HealthPath
+	}
+
+	resp, err := target.client.DoRequest(
+		ctx,
+		http.MethodGet,
+		path,
+		nil,
+		WithRequestTimeout(h.timeout),
+		WithOperationName("HealthCheck"),
+	)
+	status.LatencyMs = time.Since(status.CheckedAt).Milliseconds()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	status.StatusCode = resp.StatusCode
+	status.Healthy = isExpectedStatus(resp.StatusCode, nil)
+	if !status.Healthy {
+		status.Error = fmt.Sprintf("unexpected status code %d", resp.StatusCode)
+	}
+	return status
+}
+
+// Status returns the outcome of the latest health checks. Dependencies that have not been
+// checked yet are reported as unhealthy
+func (h *HealthChecker) Status() HealthReport {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	report := HealthReport{Healthy: true, Dependencies: []DependencyStatus{}}
+	for _, target := range h.targets {
+		status, ok := h.statuses[target.dep.DepName]
+		if !ok {
+			status = DependencyStatus{
+				Name:  target.dep.DepName,
+				URL:   target.dep.DepRootDomain,
+				Error: "not checked yet",
+			}
+		}
+		report.Healthy = report.Healthy && status.Healthy
+		report.Dependencies = append(report.Dependencies, status)
+	}
+	sort.Slice(report.Dependencies, func(i, j int) bool {
+		return report.Dependencies[i].Name < report.Dependencies[j].Name
+	})
+	return report
+}
+
+// Start checks the dependencies immediately and then periodically in the background until
+// Stop is called or the context is done
+func (h *HealthChecker) Start(ctx context.Context) {
+	h.mu.Lock()
+	if h.stop != nil {
+		h.mu.Unlock()
+		return
+	}
+	ctx, h.stop = context.WithCancel(ctx)
+	done := make(chan struct{})
+	h.done = done
+	h.mu.Unlock()
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+
+		for {
+			h.Check(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop ends the periodic health checks and waits for any check in progress to complete
+func (h *HealthChecker) Stop() {
+	h.mu.Lock()
+	stop, done := h.stop, h.done
+	h.stop, h.done = nil, nil
+	h.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	stop()
+	<-done
+}
+
+// dependencySummary is the status of a dependency served by the health handler without details
+type dependencySummary struct {
+	Name       string `json:"name"`
+	Healthy    bool   `json:"healthy"`
+	StatusCode int    `json:"statusCode,omitempty"`
+}
+
+// healthSummary is the health report served by the health handler without details
+type healthSummary struct {
+	Healthy      bool                `json:"healthy"`
+	Dependencies []dependencySummary `json:"dependencies"`
+}
+
+// summary returns the report without the details of each dependency
+func (r HealthReport) summary() healthSummary {
+	summary := healthSummary{Healthy: r.Healthy, Dependencies: []dependencySummary{}}
+	for _, status := range r.Dependencies {
+		summary.Dependencies = append(summary.Dependencies, dependencySummary{
+			Name:       status.Name,
+			Healthy:    status.Healthy,
+			StatusCode: status.StatusCode,
+		})
+	}
+	return summary
+}
+
+// Handler serves the health report as JSON e.g on `/health/dependencies`. It responds with
+// 200 when every dependency is healthy and 503 otherwise, for use as a readiness probe.
+// Only the name, health and status code of each dependency are served unless the checker was
+// created WithHealthDetails. The dependencies are checked on the first request when periodic
+// checks have not been started
+func (h *HealthChecker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.mu.RLock()
+		checked := len(h.statuses) > 0
+		h.mu.RUnlock()
+
+		report := h.Status()
+		if !checked {
+			report = h.Check(r.Context())
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if report.Healthy {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if h.details {
+			_ = json.NewEncoder(w).Encode(report)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(report.summary())
+	})
+}
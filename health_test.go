@@ -0,0 +1,164 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthChecker(t *testing.T) {
+	healthy := httptest.NewServer(interserviceclient.InterServiceAuthenticationMiddleware()(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/healthz", r.URL.Path)
+		}),
+	))
+	defer healthy.Close()
+
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unhealthy.Close()
+
+	config := interserviceclient.DepsConfig{
+		Environments: map[string][]interserviceclient.Dep{
+			"testing": {
+				{DepName: "profile", DepRootDomain: healthy.URL},
+				{DepName: "engagement", DepRootDomain: unhealthy.URL},
+			},
+		},
+	}
+
+	checker, err := interserviceclient.NewHealthChecker(
+		config,
+		interserviceclient.WithHealthEnvironment("testing"),
+		interserviceclient.WithHealthPath("healthz"),
+	)
+	assert.Nil(t, err)
+
+	report := checker.Status()
+	assert.False(t, report.Healthy)
+	assert.Equal(t, "not checked yet", report.Dependencies[0].Error)
+
+	rw := httptest.NewRecorder()
+	checker.Handler().ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/health/dependencies", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rw.Code)
+
+	report = interserviceclient.HealthReport{}
+	assert.Nil(t, json.NewDecoder(rw.Body).Decode(&report))
+	assert.False(t, report.Healthy)
+	assert.Len(t, report.Dependencies, 2)
+
+	engagement, profile := report.Dependencies[0], report.Dependencies[1]
+	assert.Equal(t, "engagement", engagement.Name)
+	assert.False(t, engagement.Healthy)
+	assert.Equal(t, http.StatusServiceUnavailable, engagement.StatusCode)
+	assert.Equal(t, "profile", profile.Name)
+	assert.True(t, profile.Healthy)
+	assert.Empty(t, profile.Error)
+	// the handler doesn't expose the topology of the service by default
+	assert.Empty(t, engagement.URL)
+	assert.True(t, engagement.CheckedAt.IsZero())
+}
+
+func TestHealthChecker_Details(t *testing.T) {
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unhealthy.Close()
+
+	config := interserviceclient.DepsConfig{
+		Environments: map[string][]interserviceclient.Dep{
+			"testing": {{DepName: "engagement", DepRootDomain: unhealthy.URL}},
+		},
+	}
+	checker, err := interserviceclient.NewHealthChecker(
+		config,
+		interserviceclient.WithHealthEnvironment("testing"),
+		interserviceclient.WithHealthDetails(),
+	)
+	assert.Nil(t, err)
+
+	rw := httptest.NewRecorder()
+	checker.Handler().ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/health/dependencies", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rw.Code)
+
+	report := interserviceclient.HealthReport{}
+	assert.Nil(t, json.NewDecoder(rw.Body).Decode(&report))
+	assert.Equal(t, unhealthy.URL, report.Dependencies[0].URL)
+	assert.False(t, report.Dependencies[0].CheckedAt.IsZero())
+}
+
+func TestHealthChecker_Start(t *testing.T) {
+	probes := make(chan struct{}, 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case probes <- struct{}{}:
+		default:
+		}
+	}))
+	defer srv.Close()
+
+	checker, err := interserviceclient.NewHealthChecker(
+		interserviceclient.DepsConfig{Environments: map[string][]interserviceclient.Dep{
+			"staging": {{DepName: "profile", DepRootDomain: srv.URL}},
+		}},
+		interserviceclient.WithHealthInterval(10*time.Millisecond),
+	)
+	assert.Nil(t, err)
+
+	checker.Start(context.Background())
+	defer checker.Stop()
+
+	for i := 0; i < 2; i++ {
+		<-probes
+	}
+	assert.Eventually(t, func() bool {
+		return checker.Status().Healthy
+	}, time.Second, 10*time.Millisecond)
+
+	rw := httptest.NewRecorder()
+	checker.Handler().ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/health/dependencies", nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+}
+
+func TestNewHealthChecker_UnknownEnvironment(t *testing.T) {
+	_, err := interserviceclient.NewHealthChecker(
+		interserviceclient.DepsConfig{},
+		interserviceclient.WithHealthEnvironment("qa"),
+	)
+	assert.NotNil(t, err)
+}
+
+func TestNewHealthChecker_InvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		_, err := interserviceclient.NewHealthChecker(
+			interserviceclient.DepsConfig{},
+			interserviceclient.WithHealthEnvironment("staging"),
+			interserviceclient.WithHealthInterval(interval),
+		)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestHealthChecker_DepHealthPath(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ready" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	checker, err := interserviceclient.NewHealthChecker(
+		interserviceclient.DepsConfig{Environments: map[string][]interserviceclient.Dep{
+			"staging": {{DepName: "profile", DepRootDomain: srv.URL, HealthPath: "ready"}},
+		}},
+	)
+	assert.Nil(t, err)
+	assert.True(t, checker.Check(context.Background()).Healthy)
+}
@@ -4,22 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"io"
 	"net/http"
-	"net/http/httputil"
-	"os"
-	"path/filepath"
+	"net/url"
+	"sort"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 	"github.com/savannahghi/firebasetools"
 	"github.com/savannahghi/serverutils"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
-	"gopkg.in/yaml.v2"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Inter service token expire minutes. Specify after how long a token will expire
@@ -36,6 +34,11 @@ type ISCService struct {
 	// The endpoint where the service serves requests. The dependant should know forehand where to
 	// this services lives
 	RootDomain string
+
+	// Additional endpoints that serve the same requests e.g a regional fallback. When a service
+	// has more than one endpoint, including the RootDomain, requests are balanced across them.
+	// RootDomain defaults to the first endpoint
+	Endpoints []Endpoint
 }
 
 // GetJWTKey returns a byte slice of the JWT secret key
@@ -56,20 +59,79 @@ type InterServiceClient struct {
 	Name              string
 	RequestRootDomain string
 	httpClient        http.Client
+	headers           map[string]string
+	userAgent         string
+	logger            logrus.FieldLogger
+	tokenSource       TokenSource
+	authMode          AuthMode
+	audience          string
+	metrics           *clientMetrics
+	tracer            trace.Tracer
+	serviceName       string
+	baseTransport     http.RoundTripper
 }
 
-// NewInterserviceClient initializes a new interservice client
-func NewInterserviceClient(s ISCService) (*InterServiceClient, error) {
+// NewInterserviceClient initializes a new interservice client. By default requests time out
+// after one minute and are traced using the global tracer provider
+func NewInterserviceClient(s ISCService, opts ...ClientOption) (*InterServiceClient, error) {
+	options := defaultClientOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	rootDomain := s.RootDomain
+	if rootDomain == "" && len(s.Endpoints) > 0 {
+		rootDomain = s.Endpoints[0].URL
+	}
+	if rootDomain == "" && options.resolver != nil {
+		// requests are addressed to the resolved endpoints
+		rootDomain = fmt.Sprintf("http://%s", s.Name)
+	}
+	primary, err := url.Parse(rootDomain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root domain for %s: %w", s.Name, err)
+	}
+
+	var endpoints []*endpointState
+	if len(s.Endpoints) > 0 {
+		endpoints, err = parseEndpoints(withRootDomain(rootDomain, s.Endpoints))
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoints for %s: %w", s.Name, err)
+		}
+	}
+
+	metrics := newClientMetrics(options.meterProvider, s.Name)
+	transport, baseTransport := options.buildTransport(s.Name, primary, endpoints, metrics)
+
 	return &InterServiceClient{
 		Name:              s.Name,
-		RequestRootDomain: s.RootDomain,
+		RequestRootDomain: rootDomain,
 		httpClient: http.Client{
-			Transport: otelhttp.NewTransport(http.DefaultTransport),
-			Timeout:   time.Duration(1 * time.Minute),
+			Transport: transport,
+			Timeout:   options.timeout,
 		},
+		headers:       options.headers,
+		userAgent:     options.userAgent,
+		logger:        options.logger,
+		tokenSource:   options.tokenSource,
+		authMode:      options.authMode,
+		audience:      options.audience,
+		metrics:       metrics,
+		tracer:        newTracer(options.tracerProvider),
+		serviceName:   options.serviceName,
+		baseTransport: baseTransport,
 	}, nil
 }
 
+// CloseIdleConnections closes the connections to the dependency that are not in use. The
+// connections of a transport shared with other clients, such as http.DefaultTransport, are
+// closed for all of them
+func (c InterServiceClient) CloseIdleConnections() {
+	if t, ok := c.baseTransport.(interface{ CloseIdleConnections() }); ok {
+		t.CloseIdleConnections()
+	}
+}
+
 // CreateAuthToken returns a signed JWT for use in authentication.
 func (c InterServiceClient) CreateAuthToken(ctx context.Context) (string, error) {
 	var expireMinutes int
@@ -86,6 +148,7 @@ func (c InterServiceClient) CreateAuthToken(ctx context.Context) (string, error)
 		jwt.StandardClaims{
 			IssuedAt:  time.Now().Unix(),
 			ExpiresAt: time.Now().Add(time.Duration(expireMinutes) * time.Minute).Unix(),
+			Audience:  c.audience,
 		},
 	}
 
@@ -98,17 +161,100 @@ func (c InterServiceClient) CreateAuthToken(ctx context.Context) (string, error)
 	return tokenString, nil
 }
 
+// authToken returns the bearer token for a request, from the configured token source if any.
+// It is empty when the client does not authenticate its requests
+func (c InterServiceClient) authToken(ctx context.Context) (string, error) {
+	if c.authMode == AuthModeNone {
+		return "", nil
+	}
+
+	var token string
+	var err error
+	if c.tokenSource != nil {
+		token, err = c.tokenSource.Token(ctx)
+	} else {
+		token, err = c.CreateAuthToken(ctx)
+	}
+	c.metrics.recordToken(ctx, err)
+	return token, err
+}
+
+// setHeaders sets the default, content negotiation, per request and authorization headers on a request
+func (c InterServiceClient) setHeaders(req *http.Request, token string, options *requestOptions) {
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", options.contentType)
+
+	if requestID, ok := RequestIDFromContext(req.Context()); ok {
+		req.Header.Set(RequestIDHeader, requestID)
+	}
+	setTimeoutHeader(req, c.httpClient.Timeout)
+	if c.serviceName != "" {
+		req.Header.Set(CallerServiceHeader, c.serviceName)
+	}
+
+	for key, values := range options.headers {
+		req.Header[key] = values
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
 // GenerateRequestURL generate a url with path for requested resource.
 func (c InterServiceClient) generateRequestURL(path string) string {
 	return fmt.Sprintf("%v/%v", c.RequestRootDomain, path)
 }
 
+// requestURL generates the url for a requested resource with the supplied query parameters
+func (c InterServiceClient) requestURL(path string, query url.Values) (string, error) {
+	u, err := url.Parse(c.generateRequestURL(path))
+	if err != nil {
+		return "", fmt.Errorf("invalid request url: %w", err)
+	}
+
+	if len(query) > 0 {
+		q := u.Query()
+		for key, values := range query {
+			for _, value := range values {
+				q.Add(key, value)
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String(), nil
+}
+
 // MakeRequest performs an inter service http request and returns a response
 func (c InterServiceClient) MakeRequest(ctx context.Context, method string, path string, body interface{}) (*http.Response, error) {
+	return c.DoRequest(ctx, method, path, body)
+}
 
-	url := c.generateRequestURL(path)
+// DoRequest performs an inter service http request customised by the supplied options and
+// returns the response. The caller is responsible for closing the response body
+func (c InterServiceClient) DoRequest(
+	ctx context.Context,
+	method string,
+	path string,
+	body interface{},
+	opts ...RequestOption,
+) (*http.Response, error) {
+	options := newRequestOptions(opts)
+
+	reqURL, err := c.requestURL(path, options.query)
+	if err != nil {
+		return nil, err
+	}
 
-	token, tknErr := c.CreateAuthToken(ctx)
+	token, tknErr := c.authToken(ctx)
 	if tknErr != nil {
 		return nil, tknErr
 	}
@@ -117,66 +263,90 @@ func (c InterServiceClient) MakeRequest(ctx context.Context, method string, path
 	// instead of having a request body. In some cases where a GET request has an empty body {},
 	// it might result in status code 400 with the error:
 	//  `Your client has issued a malformed or illegal request. That’s all we know.`
-	if method == http.MethodGet {
-		req, reqErr := http.NewRequestWithContext(ctx, method, url, nil)
-		if reqErr != nil {
-			return nil, reqErr
+	var payload io.Reader
+	switch {
+	case options.bodyReader != nil:
+		payload = options.bodyReader
+	case method != http.MethodGet:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
 		}
+		payload = bytes.NewBuffer(encoded)
+	}
 
-		req.Header.Set("Authorization", "Bearer "+token)
-		req.Header.Set("Accept", "application/json")
-		req.Header.Set("Content-Type", "application/json")
+	// correlate this request with the one being handled, starting a new chain if there is none
+	if _, ok := RequestIDFromContext(ctx); !ok {
+		ctx = ContextWithRequestID(ctx, NewRequestID())
+	}
 
-		return c.httpClient.Do(req)
+	// responses to caller specific requests are not shared with other callers
+	if identity := requestIdentity(c.tokenSource != nil, token, options); identity != "" {
+		ctx = context.WithValue(ctx, requestIdentityKey{}, identity)
 	}
 
-	encoded, err := json.Marshal(body)
-	if err != nil {
-		return nil, err
+	route := options.routeFor(path)
+	ctx, span := c.startSpan(ctx, method, route, options)
+	ctx, cancel := options.withTimeout(ctx)
+	finish := func() {
+		cancel()
+		span.End()
 	}
 
-	payload := bytes.NewBuffer(encoded)
-	req, reqErr := http.NewRequestWithContext(ctx, method, url, payload)
+	req, reqErr := http.NewRequestWithContext(ctx, method, reqURL, payload)
 	if reqErr != nil {
+		finish()
 		return nil, reqErr
 	}
 
-	if serverutils.IsDebug() {
-		r, _ := httputil.DumpRequest(req, true)
-		log.Println(string(r))
-	}
+	c.setHeaders(req, token, options)
 
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
+	done := c.metrics.startRequest(ctx, method, route)
+	resp, err := c.httpClient.Do(req)
+	done(resp, err)
+	recordResponse(span, resp, err)
+	if err != nil {
+		finish()
+		return nil, err
+	}
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: finish}
 
-	return c.httpClient.Do(req)
+	return resp, nil
 }
 
 // jwtCheckFn is a function type for authorization and authentication checks
 // there can be several e.g an authentication check runs first then an authorization
 // check runs next if the authentication passes etc
-type jwtCheckFn = func(r *http.Request) (bool, map[string]string, *jwt.Token)
+type jwtCheckFn = func(r *http.Request) (*jwt.Token, error)
 
 // InterServiceAuthenticationMiddleware handles jwt authentication
-func InterServiceAuthenticationMiddleware() func(http.Handler) http.Handler {
+func InterServiceAuthenticationMiddleware(opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	options := newMiddlewareOptions(opts)
+	metrics := newAuthMetrics(options.meterProvider)
+
 	// multiple checks can be run in sequence
-	jwtCheckFuncs := []jwtCheckFn{HasValidJWTBearerToken}
+	jwtCheckFuncs := []jwtCheckFn{validJWTBearerToken}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(
 			func(w http.ResponseWriter, r *http.Request) {
 
 				errs := []map[string]string{}
+				route := options.route(r)
 
 				for _, checkFunc := range jwtCheckFuncs {
-					shouldContinue, errMap, _ := checkFunc(r)
-					if shouldContinue {
+					_, err := checkFunc(r)
+					if err == nil {
+						metrics.record(r.Context(), route, "")
+						recordAuthOutcome(r.Context(), "")
 
 						next.ServeHTTP(w, r)
 						return
 					}
-					errs = append(errs, errMap)
+					reason := authFailureReason(err)
+					metrics.record(r.Context(), route, reason)
+					recordAuthOutcome(r.Context(), reason)
+					errs = append(errs, serverutils.ErrorMap(err))
 				}
 
 				serverutils.WriteJSONResponse(w, errs, http.StatusUnauthorized)
@@ -187,117 +357,228 @@ func InterServiceAuthenticationMiddleware() func(http.Handler) http.Handler {
 // HasValidJWTBearerToken returns true with no errors if the request has a valid bearer token in the authorization header.
 // Otherwise, it returns false and the error in a map with the key "error"
 func HasValidJWTBearerToken(r *http.Request) (bool, map[string]string, *jwt.Token) {
-	bearerToken, err := firebasetools.ExtractBearerToken(r)
+	token, err := validJWTBearerToken(r)
 	if err != nil {
-
 		return false, serverutils.ErrorMap(err), nil
 	}
 
+	return true, nil, token
+}
+
+// validJWTBearerToken parses and validates the bearer token in the authorization header
+func validJWTBearerToken(r *http.Request) (*jwt.Token, error) {
+	bearerToken, err := firebasetools.ExtractBearerToken(r)
+	if err != nil {
+		return nil, &missingTokenError{err: err}
+	}
+
 	claims := &Claims{}
 
-	token, err := jwt.ParseWithClaims(bearerToken, claims, func(token *jwt.Token) (interface{}, error) {
+	return jwt.ParseWithClaims(bearerToken, claims, func(token *jwt.Token) (interface{}, error) {
 		return GetJWTKey(), nil
 	})
+}
 
-	if err != nil {
-		return false, serverutils.ErrorMap(err), nil
+// missingTokenError is returned when a request does not carry a bearer token
+type missingTokenError struct {
+	err error
+}
+
+// Error implements the error interface
+func (e *missingTokenError) Error() string {
+	return e.err.Error()
+}
+
+// authFailureReason classifies an authentication failure for metrics
+func authFailureReason(err error) string {
+	var missing *missingTokenError
+	if errors.As(err, &missing) {
+		return "missing_token"
 	}
 
-	return true, nil, token
+	var validationErr *jwt.ValidationError
+	if errors.As(err, &validationErr) {
+		switch {
+		case validationErr.Errors&jwt.ValidationErrorMalformed != 0:
+			return "malformed_token"
+		case validationErr.Errors&jwt.ValidationErrorSignatureInvalid != 0:
+			return "invalid_signature"
+		case validationErr.Errors&jwt.ValidationErrorExpired != 0:
+			return "expired_token"
+		}
+	}
+	return "invalid_token"
 }
 
-// Dep is the dependency definition
+// Dep is the dependency definition. The optional settings tune the client created for the
+// dependency by SetupISCclient e.g
+//
+//	staging:
+//	  - depName: profile
+//	    depRootDomain: https://profile.bewell.co.ke
+//	    timeout: 10s
+//	    retry:
+//	      maxAttempts: 3
+//	      initialBackoff: 200ms
+//	    rateLimit:
+//	      requestsPerSecond: 50
+//	      burst: 10
+//	    headers:
+//	      X-Tenant: bewell
+//	    healthPath: healthz
+//	    authMode: jwt
+//	    audience: profile
 type Dep struct {
-	DepName       string `yaml:"depName"`
-	DepRootDomain string `yaml:"depRootDomain"`
+	DepName       string     `yaml:"depName"`
+	DepRootDomain string     `yaml:"depRootDomain"`
+	DepEndpoints  []Endpoint `yaml:"depEndpoints,omitempty"`
+
+	// The time limit for requests to the dependency. It defaults to one minute
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// How failed requests to the dependency are retried. They are not retried by default
+	Retry *RetryPolicy `yaml:"retry,omitempty"`
+
+	// The rate at which requests are sent to the dependency. It is unlimited by default
+	RateLimit *RateLimit `yaml:"rateLimit,omitempty"`
+
+	// Headers sent with every request to the dependency
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// The path probed by a HealthChecker. It defaults to the checker's path
+	HealthPath string `yaml:"healthPath,omitempty"`
+
+	// How requests to the dependency are authenticated. It defaults to AuthModeJWT
+	AuthMode AuthMode `yaml:"authMode,omitempty"`
+
+	// The audience claim of the inter service JWTs sent to the dependency
+	Audience string `yaml:"audience,omitempty"`
 }
 
-// DepsConfig is the config for dependencies of a particular service
+// ClientOptions returns the client options for the settings of the dependency
+func (d Dep) ClientOptions() []ClientOption {
+	opts := []ClientOption{}
+	if d.Timeout > 0 {
+		opts = append(opts, WithTimeout(d.Timeout))
+	}
+	if d.Retry != nil {
+		opts = append(opts, WithRetryPolicy(*d.Retry))
+	}
+	if d.RateLimit != nil {
+		opts = append(opts, WithRateLimit(d.RateLimit.RequestsPerSecond, d.RateLimit.Burst))
+	}
+	if len(d.Headers) > 0 {
+		opts = append(opts, WithDefaultHeaders(d.Headers))
+	}
+	if d.AuthMode != "" {
+		opts = append(opts, WithAuthMode(d.AuthMode))
+	}
+	if d.Audience != "" {
+		opts = append(opts, WithAudience(d.Audience))
+	}
+	return opts
+}
+
+// legacyProductionEnv is the key under which deps files have historically declared the
+// dependencies for the production environment, whose name is `prod`
+const legacyProductionEnv = "production"
+
+// DepsConfig is the config for dependencies of a particular service. It maps each environment
+// name e.g `staging` or `sandbox` to the dependencies used in that environment
 type DepsConfig struct {
-	Staging    []Dep `yaml:"staging"`
-	Testing    []Dep `yaml:"testing"`
-	Demo       []Dep `yaml:"demo"`
-	Production []Dep `yaml:"production"`
-	E2E        []Dep `yaml:"e2e"`
+	Environments map[string][]Dep `yaml:",inline"`
 }
 
-// PathToDepsFile return the path to deps.yaml file
-func PathToDepsFile() string {
-	cwd, _ := os.Getwd()
-	return getDepsPath(filepath.Join(cwd, DepsFileName))
+// Deps returns the dependencies declared for an environment. The production environment may
+// be declared under either `prod` or `production`
+func (c DepsConfig) Deps(environment string) ([]Dep, error) {
+	if deps, ok := c.Environments[environment]; ok {
+		return deps, nil
+	}
+	if environment == serverutils.ProdEnv {
+		if deps, ok := c.Environments[legacyProductionEnv]; ok {
+			return deps, nil
+		}
+	}
+	return nil, fmt.Errorf(
+		"the %s environment is not defined in the deps config, the defined environments are %v",
+		environment, c.EnvironmentNames(),
+	)
 }
 
-// recursively get the path to the deps.yaml file
-func getDepsPath(path string) string {
-	_, err := ioutil.ReadFile(filepath.Clean(path))
+// EnvironmentNames returns the sorted names of the environments declared in the config
+func (c DepsConfig) EnvironmentNames() []string {
+	names := []string{}
+	for name := range c.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PathToDepsFile return the path to deps.yaml file. It is empty when the file can not be found,
+// use FindDepsFile for the reason
+func PathToDepsFile() string {
+	path, err := FindDepsFile()
 	if err != nil {
-		n := strings.Split(filepath.Dir(path), "/")
-		m := n[:len(n)-1]
-		p := filepath.Join(strings.Join(m, "/"), DepsFileName)
-		return getDepsPath(p)
+		return ""
 	}
 	return path
 }
 
-// GetDepFromConfig retrives a specific config from config slice
-func GetDepFromConfig(name string, config []Dep) *Dep {
-	var d Dep
-	for _, dep := range config {
-		if dep.DepName == name {
-			d = dep
+// GetDepFromConfig retrives a specific config from config slice. It returns a
+// *DepNotFoundError when the dependency is not declared and an error when it is declared more
+// than once
+func GetDepFromConfig(name string, config []Dep) (*Dep, error) {
+	var found *Dep
+	for i, dep := range config {
+		if dep.DepName != name {
+			continue
 		}
+		if found != nil {
+			return nil, fmt.Errorf("dependency %s is declared more than once in the deps config", name)
+		}
+		found = &config[i]
 	}
-	return &d
-}
-
-// SetupISCclient returns an InterServiceClient
-func SetupISCclient(config DepsConfig, serviceName string) (*InterServiceClient, error) {
-	if serverutils.GetRunningEnvironment() == serverutils.StagingEnv {
-		dep := GetDepFromConfig(serviceName, config.Staging)
-		client, err := NewInterserviceClient(ISCService{Name: dep.DepName, RootDomain: dep.DepRootDomain})
-		return client, err
+	if found == nil {
+		return nil, &DepNotFoundError{Name: name}
 	}
 
-	if serverutils.GetRunningEnvironment() == serverutils.TestingEnv {
-		dep := GetDepFromConfig(serviceName, config.Testing)
-		client, err := NewInterserviceClient(ISCService{Name: dep.DepName, RootDomain: dep.DepRootDomain})
-		return client, err
-	}
+	d := *found
+	return &d, nil
+}
 
-	if serverutils.GetRunningEnvironment() == serverutils.DemoEnv {
-		dep := GetDepFromConfig(serviceName, config.Demo)
-		client, err := NewInterserviceClient(ISCService{Name: dep.DepName, RootDomain: dep.DepRootDomain})
-		return client, err
+// SetupISCclient returns an InterServiceClient for a dependency declared for the environment
+// the service is running in. The settings declared for the dependency are applied after the
+// supplied options, so that they can be tuned in the deps file. Use WithDepsWatcher for the
+// client to follow changes to the deps file
+func SetupISCclient(config DepsConfig, serviceName string, opts ...ClientOption) (*InterServiceClient, error) {
+	environment := serverutils.GetRunningEnvironment()
+	deps, err := config.Deps(environment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup isc client: %w", err)
 	}
 
-	if serverutils.GetRunningEnvironment() == serverutils.ProdEnv {
-		dep := GetDepFromConfig(serviceName, config.Production)
-		client, err := NewInterserviceClient(ISCService{Name: dep.DepName, RootDomain: dep.DepRootDomain})
-		return client, err
+	dep, err := GetDepFromConfig(serviceName, deps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup isc client for the %s environment: %w", environment, err)
 	}
-
-	if serverutils.GetRunningEnvironment() == E2eEnv {
-		dep := GetDepFromConfig(serviceName, config.E2E)
-		client, err := NewInterserviceClient(ISCService{Name: dep.DepName, RootDomain: dep.DepRootDomain})
-		return client, err
+	if problems := validateDeps(environment, []Dep{*dep}); len(problems) > 0 {
+		return nil, fmt.Errorf("failed to setup isc client: %w", &DepsValidationError{Problems: problems})
 	}
 
-	return nil, fmt.Errorf("failed to setup isc client")
+	return NewInterserviceClient(ISCService{
+		Name:       dep.DepName,
+		RootDomain: dep.DepRootDomain,
+		Endpoints:  dep.DepEndpoints,
+	}, append(opts, dep.ClientOptions()...)...)
 }
 
 // LoadDepsFromYAML loads the interservice dependency config from a deps.yaml
-// file that is at the default location
+// file that is at the default location, see LoadDeps. References to environment variables in
+// the file e.g `${PROFILE_URL:-https://profile.bewell.co.ke}` are replaced with their values,
+// the dependencies in a deps.local.yaml file next to it are merged on top and root domains are
+// overridden by variables like `ISC_DEP_PROFILE_URL`
 func LoadDepsFromYAML() (*DepsConfig, error) {
-	var config DepsConfig
-
-	file, err := ioutil.ReadFile(filepath.Clean(PathToDepsFile()))
-	if err != nil {
-		return nil, fmt.Errorf("can't read deps file: %w", err)
-	}
-
-	if err := yaml.Unmarshal(file, &config); err != nil {
-		return nil, fmt.Errorf("can't unmarshal deps YAML: %w", err)
-	}
-
-	return &config, nil
+	return LoadDeps()
 }
@@ -3,12 +3,14 @@ package interserviceclient_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -17,6 +19,7 @@ import (
 	"github.com/savannahghi/interserviceclient"
 	"github.com/savannahghi/serverutils"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
 )
 
 // CoverageThreshold sets the test coverage threshold below which the tests will fail
@@ -351,25 +354,37 @@ func TestGetDepFromConfig(t *testing.T) {
 		},
 	}
 
-	one := interserviceclient.GetDepFromConfig("one", deps)
+	one, err := interserviceclient.GetDepFromConfig("one", deps)
+	assert.Nil(t, err)
 	assert.NotNil(t, one)
 	assert.Equal(t, "one", one.DepName)
 	assert.Equal(t, "https://one.com", one.DepRootDomain)
 
-	two := interserviceclient.GetDepFromConfig("two", deps)
+	two, err := interserviceclient.GetDepFromConfig("two", deps)
+	assert.Nil(t, err)
 	assert.NotNil(t, two)
 	assert.Equal(t, "two", two.DepName)
 	assert.Equal(t, "https://two.com", two.DepRootDomain)
 
-	three := interserviceclient.GetDepFromConfig("three", deps)
+	three, err := interserviceclient.GetDepFromConfig("three", deps)
+	assert.Nil(t, err)
 	assert.NotNil(t, three)
 	assert.Equal(t, "three", three.DepName)
 	assert.Equal(t, "https://three.com", three.DepRootDomain)
 
-	four := interserviceclient.GetDepFromConfig("four", deps)
+	four, err := interserviceclient.GetDepFromConfig("four", deps)
+	assert.Nil(t, err)
 	assert.NotNil(t, four)
 	assert.Equal(t, "four", four.DepName)
 	assert.Equal(t, "https://four.com", four.DepRootDomain)
+
+	_, err = interserviceclient.GetDepFromConfig("five", deps)
+	var notFound *interserviceclient.DepNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+	assert.Equal(t, "five", notFound.Name)
+
+	_, err = interserviceclient.GetDepFromConfig("one", append(deps, interserviceclient.Dep{DepName: "one"}))
+	assert.NotNil(t, err)
 }
 
 func TestGetPathToDepsFile(t *testing.T) {
@@ -390,3 +405,127 @@ func TestLoadDepsFromYAML(t *testing.T) {
 		return
 	}
 }
+
+func TestDepsConfig_Deps(t *testing.T) {
+	config, err := interserviceclient.LoadDepsFromYAML()
+	assert.Nil(t, err)
+
+	staging, err := config.Deps(serverutils.StagingEnv)
+	assert.Nil(t, err)
+	assert.Equal(t, "profile", staging[0].DepName)
+
+	// existing deps files declare the production dependencies under `production`
+	prod, err := config.Deps(serverutils.ProdEnv)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://profile-prod.healthcloud.co.ke", prod[0].DepRootDomain)
+
+	var custom interserviceclient.DepsConfig
+	assert.Nil(t, yaml.Unmarshal([]byte(`
+sandbox:
+  - depName: profile
+    depRootDomain: https://profile.sandbox.example.com
+preview-jane:
+  - depName: profile
+    depRootDomain: https://profile.preview.example.com
+`), &custom))
+
+	sandbox, err := custom.Deps("sandbox")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://profile.sandbox.example.com", sandbox[0].DepRootDomain)
+	assert.Equal(t, []string{"preview-jane", "sandbox"}, custom.EnvironmentNames())
+
+	_, err = custom.Deps(serverutils.StagingEnv)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "staging")
+}
+
+func TestSetupISCclient(t *testing.T) {
+	config := interserviceclient.DepsConfig{Environments: map[string][]interserviceclient.Dep{
+		"staging": {{DepName: "profile", DepRootDomain: "https://profile.example.com"}},
+	}}
+
+	client, err := interserviceclient.SetupISCclient(config, "profile")
+	assert.Nil(t, err)
+	assert.Equal(t, "profile", client.Name)
+	assert.Equal(t, "https://profile.example.com", client.RequestRootDomain)
+
+	_, err = interserviceclient.SetupISCclient(interserviceclient.DepsConfig{}, "profile")
+	assert.NotNil(t, err)
+
+	_, err = interserviceclient.SetupISCclient(config, "engagement")
+	var notFound *interserviceclient.DepNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+
+	invalid := interserviceclient.DepsConfig{Environments: map[string][]interserviceclient.Dep{
+		"staging": {{DepName: "profile", DepRootDomain: "profile.example.com"}},
+	}}
+	_, err = interserviceclient.SetupISCclient(invalid, "profile")
+	var validationErr *interserviceclient.DepsValidationError
+	assert.True(t, errors.As(err, &validationErr))
+}
+
+func TestSetupISCclient_DepSettings(t *testing.T) {
+	var requests []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		if len(requests) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	var config interserviceclient.DepsConfig
+	assert.Nil(t, yaml.Unmarshal([]byte(fmt.Sprintf(`
+staging:
+  - depName: profile
+    depRootDomain: %s
+    timeout: 10s
+    retry:
+      maxAttempts: 2
+      initialBackoff: 1ms
+    rateLimit:
+      requestsPerSecond: 100
+    headers:
+      X-Tenant: bewell
+    authMode: none
+`, srv.URL)), &config))
+	assert.Nil(t, config.Validate())
+
+	dep := config.Environments["staging"][0]
+	assert.Equal(t, 10*time.Second, dep.Timeout)
+	assert.Equal(t, time.Millisecond, dep.Retry.InitialBackoff)
+
+	client, err := interserviceclient.SetupISCclient(config, "profile")
+	assert.Nil(t, err)
+	assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "bewell", requests[1].Header.Get("X-Tenant"))
+	assert.Empty(t, requests[1].Header.Get("Authorization"))
+	timeout, err := strconv.Atoi(requests[0].Header.Get(interserviceclient.RequestTimeoutHeader))
+	assert.Nil(t, err)
+	assert.InDelta(t, 10000, timeout, 1000)
+
+	config.Environments["staging"][0].AuthMode = "mtls"
+	err = config.Validate()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), `unknown auth mode "mtls"`)
+}
+
+func TestCreateAuthToken_Audience(t *testing.T) {
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: "https://profile.example.com"},
+		interserviceclient.WithAudience("profile"),
+	)
+	assert.Nil(t, err)
+
+	token, err := client.CreateAuthToken(context.Background())
+	assert.Nil(t, err)
+
+	claims := &interserviceclient.Claims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return interserviceclient.GetJWTKey(), nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "profile", claims.Audience)
+}
@@ -0,0 +1,340 @@
+package interserviceclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// redactedValue replaces sensitive values in logs
+	redactedValue = "[REDACTED]"
+
+	// defaultMaxLoggedBodySize is the number of body bytes logged for requests and responses
+	defaultMaxLoggedBodySize = 4096
+)
+
+// DefaultRedactedHeaders are the headers whose values are never logged
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// DefaultRedactedFields are the JSON fields and query parameters whose values are never logged.
+// They hold credentials and personally identifiable information. Fields match regardless of
+// case, underscores and dashes e.g `idToken` also matches `id_token`
+var DefaultRedactedFields = []string{
+	"msisdn",
+	"phone",
+	"phoneNumber",
+	"primaryPhone",
+	"secondaryPhoneNumbers",
+	"to",
+	"email",
+	"primaryEmailAddress",
+	"secondaryEmailAddresses",
+	"dateOfBirth",
+	"pin",
+	"otp",
+	"verificationCode",
+	"password",
+	"token",
+	"idToken",
+	"refreshToken",
+	"customToken",
+	"pushTokens",
+}
+
+// WithLogLevel sets the level at which the client logs its requests and responses.
+// It defaults to info when the DEBUG environment variable is set and debug otherwise
+func WithLogLevel(level logrus.Level) ClientOption {
+	return func(o *clientOptions) {
+		o.logLevel = &level
+	}
+}
+
+// WithRedactedHeaders adds headers whose values are redacted from the logs
+func WithRedactedHeaders(headers ...string) ClientOption {
+	return func(o *clientOptions) {
+		o.redactedHeaders = append(o.redactedHeaders, headers...)
+	}
+}
+
+// WithRedactedFields adds JSON fields and query parameters whose values are redacted from the logs
+func WithRedactedFields(fields ...string) ClientOption {
+	return func(o *clientOptions) {
+		o.redactedFields = append(o.redactedFields, fields...)
+	}
+}
+
+// WithMaxLoggedBodySize sets the number of request and response body bytes that are logged.
+// A negative size disables body logging
+func WithMaxLoggedBodySize(size int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxLoggedBodySize = size
+	}
+}
+
+// redactor removes sensitive values from headers, query strings and JSON bodies
+type redactor struct {
+	headers map[string]bool
+	fields  map[string]bool
+}
+
+// jsonFieldPattern matches `"field": value` where value is a string, an array of scalars or a
+// scalar, so that bodies that can not be decoded are still redacted
+var jsonFieldPattern = regexp.MustCompile(`("((?:[^"\\]|\\.)*)"\s*:\s*)("(?:[^"\\]|\\.)*"?|\[[^\]]*\]?|[^,{}[\]\s]+)`)
+
+// newRedactor creates a redactor for the supplied headers and fields. Matching is case insensitive
+// and fields also ignore underscores and dashes
+func newRedactor(headers []string, fields []string) *redactor {
+	r := &redactor{
+		headers: map[string]bool{},
+		fields:  map[string]bool{},
+	}
+	for _, header := range headers {
+		r.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	for _, field := range fields {
+		r.fields[fieldKey(field)] = true
+	}
+	return r
+}
+
+// fieldKey normalises a field name so that `idToken`, `id_token` and `ID-Token` match
+func fieldKey(field string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(field))
+}
+
+// redacts reports whether the values of the field are redacted
+func (r *redactor) redacts(field string) bool {
+	return r.fields[fieldKey(field)]
+}
+
+// redactHeaders returns a copy of the headers with sensitive values redacted
+func (r *redactor) redactHeaders(headers http.Header) map[string]string {
+	redacted := map[string]string{}
+	for key, values := range headers {
+		if r.headers[http.CanonicalHeaderKey(key)] {
+			redacted[key] = redactedValue
+			continue
+		}
+		redacted[key] = strings.Join(values, ", ")
+	}
+	return redacted
+}
+
+// redactURL returns the url with sensitive query parameter values redacted
+func (r *redactor) redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+
+	query := u.Query()
+	for key := range query {
+		if r.redacts(key) {
+			query.Set(key, redactedValue)
+		}
+	}
+
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// redactBody redacts sensitive fields from a JSON body. Bodies that can not be decoded,
+// such as truncated ones, are redacted by pattern matching
+func (r *redactor) redactBody(body []byte) string {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		if encoded, err := json.Marshal(r.redactValue(decoded)); err == nil {
+			return string(encoded)
+		}
+	}
+
+	if len(r.fields) == 0 {
+		return string(body)
+	}
+	return jsonFieldPattern.ReplaceAllStringFunc(string(body), func(field string) string {
+		match := jsonFieldPattern.FindStringSubmatch(field)
+		if !r.redacts(match[2]) {
+			return field
+		}
+		return match[1] + `"` + redactedValue + `"`
+	})
+}
+
+// redactValue walks a decoded JSON value replacing the values of sensitive fields
+func (r *redactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if r.redacts(key) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = r.redactValue(nested)
+		}
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = r.redactValue(nested)
+		}
+	}
+	return value
+}
+
+// loggingTransport logs inter service requests and responses with sensitive values redacted
+type loggingTransport struct {
+	next        http.RoundTripper
+	service     string
+	logger      logrus.FieldLogger
+	level       logrus.Level
+	redactor    *redactor
+	maxBodySize int
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isLevelEnabled(t.logger, t.level) {
+		return t.next.RoundTrip(req)
+	}
+
+	fields := logrus.Fields{
+		"dependency":      t.service,
+		"method":          req.Method,
+		"url":             t.redactor.redactURL(req.URL),
+		"request_headers": t.redactor.redactHeaders(req.Header),
+	}
+	if requestID := req.Header.Get(RequestIDHeader); requestID != "" {
+		fields["request_id"] = requestID
+	}
+	if body, ok := t.requestBody(req); ok {
+		fields["request_body"] = body
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	fields["duration_ms"] = time.Since(start).Milliseconds()
+
+	if err != nil {
+		t.logger.WithFields(fields).WithError(err).Log(t.level, "inter service request failed")
+		return resp, err
+	}
+
+	fields["status"] = resp.StatusCode
+	fields["response_headers"] = t.redactor.redactHeaders(resp.Header)
+	if t.maxBodySize < 0 || resp.Body == nil {
+		t.logger.WithFields(fields).Log(t.level, "inter service request")
+		return resp, nil
+	}
+
+	// the response is logged once the caller has read the start of the body, so that streamed
+	// responses are returned without waiting for the dependency
+	resp.Body = &loggedBody{
+		ReadCloser: resp.Body,
+		limit:      t.maxBodySize + 1,
+		log: func(prefix []byte) {
+			fields["response_body"] = t.formatBody(prefix)
+			t.logger.WithFields(fields).Log(t.level, "inter service request")
+		},
+	}
+	return resp, nil
+}
+
+// requestBody returns the redacted request body. Streamed bodies are not logged since reading
+// them would consume them
+func (t *loggingTransport) requestBody(req *http.Request) (string, bool) {
+	if t.maxBodySize < 0 || req.Body == nil || req.GetBody == nil {
+		return "", false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return "", false
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", false
+	}
+	return t.formatBody(data), true
+}
+
+// formatBody redacts a body and caps it at the configured size
+func (t *loggingTransport) formatBody(body []byte) string {
+	redacted := t.redactor.redactBody(body)
+	if len(body) <= t.maxBodySize {
+		return redacted
+	}
+	if len(redacted) > t.maxBodySize {
+		redacted = redacted[:t.maxBodySize]
+	}
+	return redacted + "...(truncated)"
+}
+
+// loggedBody captures the first bytes of a response body as the caller reads them and logs them
+// once enough have been read, the body ends or it is closed
+type loggedBody struct {
+	io.ReadCloser
+	limit int
+	log   func(prefix []byte)
+
+	mu     sync.Mutex
+	prefix []byte
+	logged bool
+}
+
+// Read reads from the body, capturing the bytes read until the limit is reached
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.logged {
+		if remaining := b.limit - len(b.prefix); remaining > 0 {
+			b.prefix = append(b.prefix, p[:min(n, remaining)]...)
+		}
+		if len(b.prefix) >= b.limit || err != nil {
+			b.flush()
+		}
+	}
+	return n, err
+}
+
+// Close closes the body, logging what was read of it if that has not been done
+func (b *loggedBody) Close() error {
+	b.mu.Lock()
+	if !b.logged {
+		b.flush()
+	}
+	b.mu.Unlock()
+	return b.ReadCloser.Close()
+}
+
+// flush logs the captured bytes. The caller must hold the lock
+func (b *loggedBody) flush() {
+	b.logged = true
+	b.log(b.prefix)
+}
+
+// isLevelEnabled reports whether the logger emits entries at the level, when it is able to tell
+func isLevelEnabled(logger logrus.FieldLogger, level logrus.Level) bool {
+	switch l := logger.(type) {
+	case *logrus.Logger:
+		return l.IsLevelEnabled(level)
+	case *logrus.Entry:
+		return l.Logger.IsLevelEnabled(level)
+	}
+	return true
+}
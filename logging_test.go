@@ -0,0 +1,220 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/savannahghi/profileutils"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterServiceClient_Logging(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = w.Write([]byte(`{"profile":{"phoneNumber":"+254711223344","name":"Test"},"otp":"1234"}`))
+	}))
+	defer srv.Close()
+
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithLogger(logger),
+		interserviceclient.WithLogLevel(logrus.DebugLevel),
+		interserviceclient.WithRedactedHeaders("X-Tenant"),
+		interserviceclient.WithRedactedFields("nationalID"),
+		interserviceclient.WithDefaultHeaders(map[string]string{"X-Tenant": "bewell"}),
+	)
+	assert.Nil(t, err)
+
+	type profile struct {
+		Name        string `json:"name"`
+		PhoneNumber string `json:"phoneNumber"`
+	}
+	type response struct {
+		Profile profile `json:"profile"`
+		OTP     string  `json:"otp"`
+	}
+
+	got, err := interserviceclient.Do[response](
+		context.Background(),
+		client,
+		http.MethodPost,
+		"users?msisdn=%2B254711223344",
+		map[string]interface{}{"to": []string{"+254711223344"}, "nationalID": "123", "message": "hi"},
+	)
+	assert.Nil(t, err)
+	// the caller still receives the full response body
+	assert.Equal(t, "+254711223344", got.Profile.PhoneNumber)
+	assert.Equal(t, "1234", got.OTP)
+
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatal("expected the request to be logged")
+	}
+	assert.Equal(t, logrus.DebugLevel, entry.Level)
+	assert.Equal(t, "profile", entry.Data["dependency"])
+	assert.Equal(t, http.StatusOK, entry.Data["status"])
+
+	requestHeaders := entry.Data["request_headers"].(map[string]string)
+	assert.Equal(t, "[REDACTED]", requestHeaders["Authorization"])
+	assert.Equal(t, "[REDACTED]", requestHeaders["X-Tenant"])
+	responseHeaders := entry.Data["response_headers"].(map[string]string)
+	assert.Equal(t, "[REDACTED]", responseHeaders["Set-Cookie"])
+
+	assert.NotContains(t, entry.Data["url"], "254711223344")
+	assert.NotContains(t, entry.Data["request_body"], "254711223344")
+	assert.NotContains(t, entry.Data["request_body"], "123")
+	assert.Contains(t, entry.Data["request_body"], "hi")
+	assert.NotContains(t, entry.Data["response_body"], "254711223344")
+	assert.NotContains(t, entry.Data["response_body"], "1234")
+	assert.Contains(t, entry.Data["response_body"], "Test")
+}
+
+func TestInterServiceClient_LoggingTruncatesBodies(t *testing.T) {
+	body := `{"phoneNumber":"+254711223344","notes":"` + strings.Repeat("a", 100) + `"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	logger, hook := test.NewNullLogger()
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithLogger(logger),
+		interserviceclient.WithLogLevel(logrus.InfoLevel),
+		interserviceclient.WithMaxLoggedBodySize(40),
+	)
+	assert.Nil(t, err)
+
+	got, err := interserviceclient.Do[map[string]string](context.Background(), client, http.MethodGet, "users", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Repeat("a", 100), got["notes"])
+
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatal("expected the request to be logged")
+	}
+	loggedBody := entry.Data["response_body"].(string)
+	assert.True(t, strings.HasSuffix(loggedBody, "...(truncated)"))
+	assert.NotContains(t, loggedBody, "254711223344")
+}
+
+func TestInterServiceClient_LoggingDisabledLevel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.InfoLevel)
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithLogger(logger),
+		interserviceclient.WithLogLevel(logrus.DebugLevel),
+	)
+	assert.Nil(t, err)
+
+	err = interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil)
+	assert.Nil(t, err)
+	assert.Empty(t, hook.AllEntries())
+}
+
+func TestInterServiceClient_LoggingStreamedResponse(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		_, _ = w.Write([]byte("data: last\n\n"))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	logger, hook := test.NewNullLogger()
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "events", RootDomain: srv.URL},
+		interserviceclient.WithLogger(logger),
+		interserviceclient.WithLogLevel(logrus.InfoLevel),
+	)
+	assert.Nil(t, err)
+
+	// the response is returned while the dependency is still streaming
+	resp, err := client.DoRequest(context.Background(), http.MethodGet, "events", nil)
+	assert.Nil(t, err)
+	assert.Empty(t, hook.AllEntries())
+
+	first := make([]byte, len("data: first\n\n"))
+	_, err = io.ReadFull(resp.Body, first)
+	assert.Nil(t, err)
+	assert.Equal(t, "data: first\n\n", string(first))
+
+	assert.Nil(t, resp.Body.Close())
+	entry := hook.LastEntry()
+	if entry == nil {
+		t.Fatal("expected the request to be logged")
+	}
+	assert.Equal(t, "data: first\n\n", entry.Data["response_body"])
+	assert.Len(t, hook.AllEntries(), 1)
+}
+
+func TestInterServiceClient_LoggingRedactsUserResponse(t *testing.T) {
+	idToken, customToken := "id-token-secret", "custom-token-secret"
+	primaryPhone, primaryEmail := "+254711223344", "test@example.com"
+	user := profileutils.UserResponse{
+		Profile: &profileutils.UserProfile{
+			ID:                  "profile-id",
+			PrimaryPhone:        &primaryPhone,
+			PrimaryEmailAddress: &primaryEmail,
+		},
+		Auth: profileutils.AuthCredentialResponse{
+			IDToken:      &idToken,
+			CustomToken:  &customToken,
+			RefreshToken: "refresh-token-secret",
+			UID:          "test-uid",
+		},
+	}
+	body, err := json.Marshal(user)
+	assert.Nil(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	secrets := []string{"+254711223344", "test@example.com", "id-token-secret", "custom-token-secret", "refresh-token-secret"}
+	// bodies that are truncated can't be decoded and are redacted by pattern matching
+	for _, size := range []int{len(body), len(body) - 10} {
+		logger, hook := test.NewNullLogger()
+		client, err := interserviceclient.NewInterserviceClient(
+			interserviceclient.ISCService{Name: "onboarding", RootDomain: srv.URL},
+			interserviceclient.WithLogger(logger),
+			interserviceclient.WithLogLevel(logrus.InfoLevel),
+			interserviceclient.WithMaxLoggedBodySize(size),
+		)
+		assert.Nil(t, err)
+
+		got, err := interserviceclient.Do[profileutils.UserResponse](context.Background(), client, http.MethodPost, "testing/login_by_phone", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, "id-token-secret", *got.Auth.IDToken)
+
+		entry := hook.LastEntry()
+		if entry == nil {
+			t.Fatal("expected the request to be logged")
+		}
+		logged := entry.Data["response_body"].(string)
+		assert.Contains(t, logged, "profile-id")
+		for _, secret := range secrets {
+			assert.NotContains(t, logged, secret)
+		}
+	}
+}
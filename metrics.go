@@ -0,0 +1,273 @@
+package interserviceclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// instrumentationName identifies the metrics and traces produced by this library
+const instrumentationName = "github.com/savannahghi/interserviceclient"
+
+// metric attribute keys
+const (
+	dependencyKey = attribute.Key("isc.dependency")
+	methodKey     = attribute.Key("http.method")
+	routeKey      = attribute.Key("http.route")
+	statusKey     = attribute.Key("http.status_code")
+	outcomeKey    = attribute.Key("isc.outcome")
+	reasonKey     = attribute.Key("isc.reason")
+	cacheKey      = attribute.Key("isc.cache")
+	endpointKey   = attribute.Key("isc.endpoint")
+)
+
+// WithMeterProvider sets the meter provider used to record client metrics.
+// The default is the global meter provider
+func WithMeterProvider(meterProvider metric.MeterProvider) ClientOption {
+	return func(o *clientOptions) {
+		o.meterProvider = meterProvider
+	}
+}
+
+// WithRouteTemplate sets the route recorded in metrics and traces for this request e.g
+// `users/{id}`. It defaults to the request path, which should be avoided for paths that
+// contain identifiers
+func WithRouteTemplate(route string) RequestOption {
+	return func(o *requestOptions) {
+		o.route = route
+	}
+}
+
+// routeFor returns the route template recorded for a request to the path
+func (o *requestOptions) routeFor(path string) string {
+	if o.route != "" {
+		return o.route
+	}
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
+
+// clientMetrics records the RED metrics of an InterServiceClient
+type clientMetrics struct {
+	dependency attribute.KeyValue
+	requests   metric.Int64Counter
+	duration   metric.Float64Histogram
+	inFlight   metric.Int64UpDownCounter
+	tokens     metric.Int64Counter
+	cache      metric.Int64Counter
+	coalesced  metric.Int64Counter
+	retries    metric.Int64Counter
+	ejections  metric.Int64Counter
+	breakers   metric.Int64UpDownCounter
+}
+
+// newClientMetrics creates the client instruments. Instruments that fail to be created are
+// replaced with no-op ones so that metrics never break requests
+func newClientMetrics(meterProvider metric.MeterProvider, dependency string) *clientMetrics {
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+	meter := meterProvider.Meter(instrumentationName)
+
+	m := &clientMetrics{dependency: dependencyKey.String(dependency)}
+
+	var err error
+	if m.requests, err = meter.Int64Counter(
+		"isc.client.requests",
+		metric.WithDescription("Number of inter service requests made"),
+	); err != nil {
+		otel.Handle(err)
+		m.requests = noop.Int64Counter{}
+	}
+	if m.duration, err = meter.Float64Histogram(
+		"isc.client.duration",
+		metric.WithDescription("Duration of inter service requests"),
+		metric.WithUnit("s"),
+	); err != nil {
+		otel.Handle(err)
+		m.duration = noop.Float64Histogram{}
+	}
+	if m.inFlight, err = meter.Int64UpDownCounter(
+		"isc.client.in_flight",
+		metric.WithDescription("Number of inter service requests in progress"),
+	); err != nil {
+		otel.Handle(err)
+		m.inFlight = noop.Int64UpDownCounter{}
+	}
+	if m.tokens, err = meter.Int64Counter(
+		"isc.client.tokens",
+		metric.WithDescription("Number of inter service auth tokens created"),
+	); err != nil {
+		otel.Handle(err)
+		m.tokens = noop.Int64Counter{}
+	}
+	if m.cache, err = meter.Int64Counter(
+		"isc.client.cache",
+		metric.WithDescription("Number of cached response lookups, by result"),
+	); err != nil {
+		otel.Handle(err)
+		m.cache = noop.Int64Counter{}
+	}
+	if m.coalesced, err = meter.Int64Counter(
+		"isc.client.coalesced",
+		metric.WithDescription("Number of requests served by an identical request that was in flight"),
+	); err != nil {
+		otel.Handle(err)
+		m.coalesced = noop.Int64Counter{}
+	}
+	if m.retries, err = meter.Int64Counter(
+		"isc.client.retries",
+		metric.WithDescription("Number of inter service requests retried, by the reason for the retry"),
+	); err != nil {
+		otel.Handle(err)
+		m.retries = noop.Int64Counter{}
+	}
+	if m.ejections, err = meter.Int64Counter(
+		"isc.client.ejections",
+		metric.WithDescription("Number of times an endpoint was ejected after repeated failures"),
+	); err != nil {
+		otel.Handle(err)
+		m.ejections = noop.Int64Counter{}
+	}
+	if m.breakers, err = meter.Int64UpDownCounter(
+		"isc.client.breakers.open",
+		metric.WithDescription("Number of endpoints whose circuit breaker is open, i.e that were ejected and have not served a request successfully since"),
+	); err != nil {
+		otel.Handle(err)
+		m.breakers = noop.Int64UpDownCounter{}
+	}
+
+	return m
+}
+
+// startRequest records a request as in flight. The returned function records its completion
+func (m *clientMetrics) startRequest(ctx context.Context, method string, route string) func(resp *http.Response, err error) {
+	if m == nil {
+		return func(*http.Response, error) {}
+	}
+
+	start := time.Now()
+	m.inFlight.Add(ctx, 1, metric.WithAttributes(m.dependency))
+
+	return func(resp *http.Response, err error) {
+		m.inFlight.Add(ctx, -1, metric.WithAttributes(m.dependency))
+
+		attrs := []attribute.KeyValue{m.dependency, methodKey.String(method), routeKey.String(route)}
+		if err != nil {
+			attrs = append(attrs, outcomeKey.String("error"))
+		} else {
+			attrs = append(attrs, statusKey.Int(resp.StatusCode))
+		}
+
+		m.requests.Add(ctx, 1, metric.WithAttributes(attrs...))
+		m.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	}
+}
+
+// recordToken records the creation of an auth token
+func (m *clientMetrics) recordToken(ctx context.Context, err error) {
+	if m == nil {
+		return
+	}
+	m.tokens.Add(ctx, 1, metric.WithAttributes(m.dependency, outcomeKey.String(outcome(err))))
+}
+
+// recordCache records the result of a cache lookup i.e hit, revalidated or miss
+func (m *clientMetrics) recordCache(ctx context.Context, result string) {
+	if m == nil {
+		return
+	}
+	m.cache.Add(ctx, 1, metric.WithAttributes(m.dependency, cacheKey.String(strings.ToLower(result))))
+}
+
+// recordCoalesced records a request that shared the response of an identical one
+func (m *clientMetrics) recordCoalesced(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	m.coalesced.Add(ctx, 1, metric.WithAttributes(m.dependency))
+}
+
+// recordRetry records a request that was retried and the reason for the retry
+func (m *clientMetrics) recordRetry(ctx context.Context, reason string) {
+	if m == nil {
+		return
+	}
+	m.retries.Add(ctx, 1, metric.WithAttributes(m.dependency, reasonKey.String(reason)))
+}
+
+// recordEjection records the ejection of an endpoint
+func (m *clientMetrics) recordEjection(ctx context.Context, endpoint string) {
+	if m == nil {
+		return
+	}
+	m.ejections.Add(ctx, 1, metric.WithAttributes(m.dependency, endpointKey.String(endpoint)))
+}
+
+// recordBreaker records the circuit breaker of an endpoint opening or closing
+func (m *clientMetrics) recordBreaker(ctx context.Context, endpoint string, open bool) {
+	if m == nil {
+		return
+	}
+	var change int64 = -1
+	if open {
+		change = 1
+	}
+	m.breakers.Add(ctx, change, metric.WithAttributes(m.dependency, endpointKey.String(endpoint)))
+}
+
+// outcome describes the result of an operation in metrics
+func outcome(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+// authMetrics records the outcome of inter service authentication checks
+type authMetrics struct {
+	authentications metric.Int64Counter
+}
+
+// newAuthMetrics creates the authentication middleware instruments
+func newAuthMetrics(meterProvider metric.MeterProvider) *authMetrics {
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+	meter := meterProvider.Meter(instrumentationName)
+
+	m := &authMetrics{}
+
+	var err error
+	if m.authentications, err = meter.Int64Counter(
+		"isc.server.authentications",
+		metric.WithDescription("Number of inter service authentication checks"),
+	); err != nil {
+		otel.Handle(err)
+		m.authentications = noop.Int64Counter{}
+	}
+
+	return m
+}
+
+// record records the outcome of an authentication check and the reason for any failure
+func (m *authMetrics) record(ctx context.Context, route string, reason string) {
+	attrs := []attribute.KeyValue{}
+	if route != "" {
+		attrs = append(attrs, routeKey.String(route))
+	}
+	if reason == "" {
+		attrs = append(attrs, outcomeKey.String("success"))
+	} else {
+		attrs = append(attrs, outcomeKey.String("failure"), reasonKey.String(reason))
+	}
+	m.authentications.Add(ctx, 1, metric.WithAttributes(attrs...))
+}
@@ -0,0 +1,131 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// collectMetrics returns the metrics recorded by a reader keyed by name
+func collectMetrics(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("can't collect metrics: %v", err)
+	}
+
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+// sumFor returns the value of the data point of a sum with the supplied attribute
+func sumFor(t *testing.T, data metricdata.Aggregation, attr attribute.KeyValue) int64 {
+	sum, ok := data.(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("expected an int64 sum, got %T", data)
+	}
+	var total int64
+	for _, dp := range sum.DataPoints {
+		if value, ok := dp.Attributes.Value(attr.Key); ok && value == attr.Value {
+			total += dp.Value
+		}
+	}
+	return total
+}
+
+func TestInterServiceClient_Metrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithMeterProvider(meterProvider),
+	)
+	assert.Nil(t, err)
+
+	ctx := context.Background()
+	assert.Nil(t, interserviceclient.Call(ctx, client, http.MethodGet, "users/1", nil,
+		interserviceclient.WithRouteTemplate("users/{id}")))
+	assert.Nil(t, interserviceclient.Call(ctx, client, http.MethodGet, "users/2", nil,
+		interserviceclient.WithRouteTemplate("users/{id}")))
+	assert.NotNil(t, interserviceclient.Call(ctx, client, http.MethodGet, "fail", nil))
+
+	metrics := collectMetrics(t, reader)
+
+	requests := metrics["isc.client.requests"]
+	assert.Equal(t, int64(3), sumFor(t, requests, attribute.String("isc.dependency", "profile")))
+	assert.Equal(t, int64(2), sumFor(t, requests, attribute.String("http.route", "users/{id}")))
+	assert.Equal(t, int64(1), sumFor(t, requests, attribute.Int("http.status_code", http.StatusInternalServerError)))
+
+	assert.Equal(t, int64(0), sumFor(t, metrics["isc.client.in_flight"], attribute.String("isc.dependency", "profile")))
+	assert.Equal(t, int64(3), sumFor(t, metrics["isc.client.tokens"], attribute.String("isc.outcome", "success")))
+
+	duration, ok := metrics["isc.client.duration"].(metricdata.Histogram[float64])
+	assert.True(t, ok)
+	var count uint64
+	for _, dp := range duration.DataPoints {
+		count += dp.Count
+	}
+	assert.Equal(t, uint64(3), count)
+}
+
+func TestInterServiceAuthenticationMiddleware_Metrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	mw := interserviceclient.InterServiceAuthenticationMiddleware(
+		interserviceclient.WithMiddlewareMeterProvider(meterProvider),
+		interserviceclient.WithRouteFunc(func(r *http.Request) string { return "/internal/users" }),
+	)
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	service, _ := interserviceclient.NewInterserviceClient(interserviceclient.ISCService{Name: "otp", RootDomain: "https://example.com"})
+	validToken, _ := service.CreateAuthToken(context.Background())
+	invalidToken, _ := createInvalidAuthToken()
+
+	for _, header := range []string{"", "Bearer " + validToken, "Bearer " + invalidToken, "Bearer not-a-jwt"} {
+		req := httptest.NewRequest(http.MethodPost, "/internal/users/1", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+	}
+
+	authentications := collectMetrics(t, reader)["isc.server.authentications"]
+	tests := []struct {
+		attr attribute.KeyValue
+		want int64
+	}{
+		{attr: attribute.String("http.route", "/internal/users"), want: 4},
+		{attr: attribute.String("isc.outcome", "success"), want: 1},
+		{attr: attribute.String("isc.outcome", "failure"), want: 3},
+		{attr: attribute.String("isc.reason", "missing_token"), want: 1},
+		{attr: attribute.String("isc.reason", "invalid_signature"), want: 1},
+		{attr: attribute.String("isc.reason", "malformed_token"), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s=%s", tt.attr.Key, tt.attr.Value.Emit()), func(t *testing.T) {
+			assert.Equal(t, tt.want, sumFor(t, authentications, tt.attr))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package interserviceclient
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MiddlewareOption configures the inter service middleware
+type MiddlewareOption func(*middlewareOptions)
+
+// middlewareOptions holds the settings of the inter service middleware
+type middlewareOptions struct {
+	meterProvider  metric.MeterProvider
+	routeFunc      func(r *http.Request) string
+	tracerProvider trace.TracerProvider
+	propagators    propagation.TextMapPropagator
+}
+
+// newMiddlewareOptions applies the supplied options to the default middleware settings
+func newMiddlewareOptions(opts []MiddlewareOption) *middlewareOptions {
+	options := &middlewareOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithMiddlewareMeterProvider sets the meter provider used to record middleware metrics.
+// The default is the global meter provider
+func WithMiddlewareMeterProvider(meterProvider metric.MeterProvider) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.meterProvider = meterProvider
+	}
+}
+
+// WithRouteFunc sets the function that returns the route template of a request e.g `users/{id}`,
+// as matched by the router. Without it metrics are not labelled by route
+func WithRouteFunc(routeFunc func(r *http.Request) string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.routeFunc = routeFunc
+	}
+}
+
+// route returns the route template of a request
+func (o *middlewareOptions) route(r *http.Request) string {
+	if o.routeFunc == nil {
+		return ""
+	}
+	return o.routeFunc(r)
+}
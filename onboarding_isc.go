@@ -2,9 +2,7 @@ package interserviceclient
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 
 	"github.com/savannahghi/profileutils"
@@ -22,11 +20,11 @@ type OnboardingService interface {
 
 //OnboardingServiceImpl represents the implemented methods in this ISC
 type OnboardingServiceImpl struct {
-	isc *InterServiceClient
+	isc Client
 }
 
 //NewOnboardingService initializes a new instance of OnboardingServiceImpl
-func NewOnboardingService(isc *InterServiceClient) *OnboardingServiceImpl {
+func NewOnboardingService(isc Client) *OnboardingServiceImpl {
 	return &OnboardingServiceImpl{
 		isc: isc,
 	}
@@ -34,25 +32,17 @@ func NewOnboardingService(isc *InterServiceClient) *OnboardingServiceImpl {
 
 //RegisterUser makes the request to register a user
 func (o *OnboardingServiceImpl) RegisterUser(ctx context.Context, payload interface{}) (*profileutils.UserProfile, error) {
-	res, err := o.isc.MakeRequest(ctx, http.MethodPost, registerUser, payload)
+	userprofile, err := Do[profileutils.UserProfile](
+		ctx,
+		o.isc,
+		http.MethodPost,
+		registerUser,
+		payload,
+		ExpectStatus(http.StatusOK),
+		WithOperationName("RegisterUser"),
+	)
 	if err != nil {
-		return nil, fmt.Errorf("unable to send request, error: %v", err)
-	}
-
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("register user failed with status code: %v", res.StatusCode)
-	}
-
-	data, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return nil, fmt.Errorf("unable to read response body: %w", err)
-	}
-
-	userprofile := profileutils.UserProfile{}
-
-	err = json.Unmarshal(data, &userprofile)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing response body, %v", err)
+		return nil, fmt.Errorf("register user failed: %w", err)
 	}
 
 	return &userprofile, nil
@@ -0,0 +1,306 @@
+package interserviceclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/savannahghi/serverutils"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultTimeout is the time limit for requests made by an InterServiceClient
+const defaultTimeout = 1 * time.Minute
+
+// TokenSource provides the bearer tokens used to authenticate inter service requests
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc adapts an ordinary function to a TokenSource
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token calls f(ctx)
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// ClientOption configures an InterServiceClient
+type ClientOption func(*clientOptions)
+
+// clientOptions holds the settings used to construct an InterServiceClient
+type clientOptions struct {
+	timeout             time.Duration
+	transport           http.RoundTripper
+	proxy               func(*http.Request) (*url.URL, error)
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	maxConnsPerHost     int
+	headers             map[string]string
+	userAgent           string
+	logger              logrus.FieldLogger
+	tokenSource         TokenSource
+	tracerProvider      trace.TracerProvider
+	logLevel            *logrus.Level
+	redactedHeaders     []string
+	redactedFields      []string
+	maxLoggedBodySize   int
+	meterProvider       metric.MeterProvider
+	serviceName         string
+	propagators         propagation.TextMapPropagator
+	cacheStore          CacheStore
+	coalesce            bool
+	coalescingHeaders   []string
+	balancingStrategy   BalancingStrategy
+	maxEndpointFailures int
+	ejectionTime        time.Duration
+	resolver            Resolver
+	retryPolicy         RetryPolicy
+	rateLimit           RateLimit
+	authMode            AuthMode
+	audience            string
+}
+
+// defaultClientOptions returns the settings used when no options are supplied
+func defaultClientOptions() *clientOptions {
+	return &clientOptions{
+		timeout:             defaultTimeout,
+		headers:             map[string]string{},
+		logger:              logrus.StandardLogger(),
+		redactedHeaders:     append([]string{}, DefaultRedactedHeaders...),
+		redactedFields:      append([]string{}, DefaultRedactedFields...),
+		maxLoggedBodySize:   defaultMaxLoggedBodySize,
+		balancingStrategy:   RoundRobinBalancing,
+		maxEndpointFailures: defaultMaxEndpointFailures,
+		ejectionTime:        defaultEjectionTime,
+		authMode:            AuthModeJWT,
+	}
+}
+
+// WithTimeout sets the time limit for requests made by the client. A zero timeout means no timeout
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithTransport sets the base transport used to make requests. It is wrapped with tracing
+// instrumentation. The default is http.DefaultTransport
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(o *clientOptions) {
+		o.transport = transport
+	}
+}
+
+// WithProxy routes requests through the proxy at the supplied URL.
+// It only applies when the base transport is an *http.Transport
+func WithProxy(proxyURL *url.URL) ClientOption {
+	return func(o *clientOptions) {
+		o.proxy = http.ProxyURL(proxyURL)
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections kept across all hosts.
+// It only applies when the base transport is an *http.Transport
+func WithMaxIdleConns(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxIdleConns = n
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle connections kept per host.
+// It only applies when the base transport is an *http.Transport
+func WithMaxIdleConnsPerHost(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxIdleConnsPerHost = n
+	}
+}
+
+// WithMaxConnsPerHost limits the total number of connections per host.
+// It only applies when the base transport is an *http.Transport
+func WithMaxConnsPerHost(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxConnsPerHost = n
+	}
+}
+
+// WithDefaultHeaders sets headers that are sent with every request made by the client.
+// They can not override the authorization and content negotiation headers set by the client
+func WithDefaultHeaders(headers map[string]string) ClientOption {
+	return func(o *clientOptions) {
+		for key, value := range headers {
+			o.headers[key] = value
+		}
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request made by the client
+func WithUserAgent(userAgent string) ClientOption {
+	return func(o *clientOptions) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithLogger sets the logger used by the client. The default is the standard logrus logger
+func WithLogger(logger logrus.FieldLogger) ClientOption {
+	return func(o *clientOptions) {
+		if logger != nil {
+			o.logger = logger
+		}
+	}
+}
+
+// WithTokenSource sets the source of the bearer tokens sent by the client.
+// The default signs a JWT using the key in the `JWT_KEY` environment variable
+func WithTokenSource(tokenSource TokenSource) ClientOption {
+	return func(o *clientOptions) {
+		o.tokenSource = tokenSource
+	}
+}
+
+// AuthMode is how a client authenticates its requests to a dependency
+type AuthMode string
+
+const (
+	// AuthModeJWT sends a bearer token, either an inter service JWT or one from the token source
+	AuthModeJWT AuthMode = "jwt"
+
+	// AuthModeNone sends requests without an Authorization header e.g to public dependencies
+	AuthModeNone AuthMode = "none"
+)
+
+// WithAuthMode sets how requests are authenticated. The default is AuthModeJWT
+func WithAuthMode(mode AuthMode) ClientOption {
+	return func(o *clientOptions) {
+		o.authMode = mode
+	}
+}
+
+// WithAudience sets the audience claim of the inter service JWTs created by the client,
+// identifying the dependency the token is meant for
+func WithAudience(audience string) ClientOption {
+	return func(o *clientOptions) {
+		o.audience = audience
+	}
+}
+
+// WithTracerProvider sets the tracer provider used to trace requests.
+// The default is the global tracer provider
+func WithTracerProvider(tracerProvider trace.TracerProvider) ClientOption {
+	return func(o *clientOptions) {
+		o.tracerProvider = tracerProvider
+	}
+}
+
+// customisesTransport reports whether any of the options tune an *http.Transport
+func (o *clientOptions) customisesTransport() bool {
+	return o.proxy != nil || o.maxIdleConns > 0 || o.maxIdleConnsPerHost > 0 || o.maxConnsPerHost > 0
+}
+
+// level returns the level at which requests are logged. Requests are visible at the default
+// logger level when running in debug mode
+func (o *clientOptions) level() logrus.Level {
+	if o.logLevel != nil {
+		return *o.logLevel
+	}
+	if serverutils.IsDebug() {
+		return logrus.InfoLevel
+	}
+	return logrus.DebugLevel
+}
+
+// buildTransport returns the instrumented transport described by the options and the base
+// transport that holds its connections. Requests are spread across the endpoints when a
+// dependency has more than one or they are resolved, and retries are rate limited like any
+// other request
+func (o *clientOptions) buildTransport(
+	service string,
+	primary *url.URL,
+	endpoints []*endpointState,
+	metrics *clientMetrics,
+) (http.RoundTripper, http.RoundTripper) {
+	base := o.transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if t, ok := base.(*http.Transport); ok && o.customisesTransport() {
+		t = t.Clone()
+		if o.proxy != nil {
+			t.Proxy = o.proxy
+		}
+		if o.maxIdleConns > 0 {
+			t.MaxIdleConns = o.maxIdleConns
+		}
+		if o.maxIdleConnsPerHost > 0 {
+			t.MaxIdleConnsPerHost = o.maxIdleConnsPerHost
+		}
+		if o.maxConnsPerHost > 0 {
+			t.MaxConnsPerHost = o.maxConnsPerHost
+		}
+		base = t
+	}
+
+	var otelOpts []otelhttp.Option
+	if o.tracerProvider != nil {
+		otelOpts = append(otelOpts, otelhttp.WithTracerProvider(o.tracerProvider))
+	}
+	if o.propagators != nil {
+		otelOpts = append(otelOpts, otelhttp.WithPropagators(o.propagators))
+	}
+	var next http.RoundTripper = otelhttp.NewTransport(base, otelOpts...)
+	if len(endpoints) > 1 || o.resolver != nil {
+		next = &balancingTransport{
+			next:         next,
+			service:      service,
+			resolver:     o.resolver,
+			primary:      primary,
+			endpoints:    endpoints,
+			strategy:     o.balancingStrategy,
+			maxFailures:  o.maxEndpointFailures,
+			ejectionTime: o.ejectionTime,
+			metrics:      metrics,
+			now:          time.Now,
+		}
+	}
+	if o.rateLimit.RequestsPerSecond > 0 {
+		next = newRateLimitTransport(next, o.rateLimit)
+	}
+	if o.retryPolicy.MaxAttempts > 1 {
+		next = &retryTransport{
+			next:    next,
+			policy:  o.retryPolicy,
+			metrics: metrics,
+		}
+	}
+	if o.coalesce {
+		next = &coalescingTransport{
+			next:    next,
+			headers: o.coalescingHeaders,
+			metrics: metrics,
+		}
+	}
+	if o.cacheStore != nil {
+		next = &cacheTransport{
+			next:    next,
+			service: service,
+			store:   o.cacheStore,
+			metrics: metrics,
+			now:     time.Now,
+		}
+	}
+
+	return &loggingTransport{
+		next:        next,
+		service:     service,
+		logger:      o.logger,
+		level:       o.level(),
+		redactor:    newRedactor(o.redactedHeaders, o.redactedFields),
+		maxBodySize: o.maxLoggedBodySize,
+	}, base
+}
@@ -0,0 +1,111 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// roundTripperFunc adapts a function to an http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestNewInterserviceClient_Options(t *testing.T) {
+	ctx := context.Background()
+
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	transportCalls := 0
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		transportCalls++
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithTransport(transport),
+		interserviceclient.WithDefaultHeaders(map[string]string{
+			"X-Tenant":      "bewell",
+			"Authorization": "must not override",
+		}),
+		interserviceclient.WithUserAgent("onboarding/1.0"),
+		interserviceclient.WithTokenSource(interserviceclient.TokenSourceFunc(
+			func(ctx context.Context) (string, error) {
+				return "static-token", nil
+			},
+		)),
+		interserviceclient.WithTracerProvider(tracerProvider),
+	)
+	assert.Nil(t, err)
+
+	err = interserviceclient.Call(ctx, client, http.MethodPost, "users", nil)
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, transportCalls)
+	assert.Equal(t, "bewell", got.Header.Get("X-Tenant"))
+	assert.Equal(t, "onboarding/1.0", got.Header.Get("User-Agent"))
+	assert.Equal(t, "Bearer static-token", got.Header.Get("Authorization"))
+	// the client span and the HTTP span of the instrumented transport
+	assert.Len(t, recorder.Ended(), 2)
+}
+
+func TestNewInterserviceClient_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithTimeout(20*time.Millisecond),
+	)
+	assert.Nil(t, err)
+
+	err = interserviceclient.Call(context.Background(), client, http.MethodGet, "slow", nil)
+	assert.NotNil(t, err)
+	assert.True(t, interserviceclient.IsRetryable(err))
+}
+
+func TestNewInterserviceClient_Proxy(t *testing.T) {
+	proxied := false
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = true
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	assert.Nil(t, err)
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: "http://profile.internal"},
+		interserviceclient.WithProxy(proxyURL),
+		interserviceclient.WithMaxIdleConns(10),
+		interserviceclient.WithMaxIdleConnsPerHost(5),
+		interserviceclient.WithMaxConnsPerHost(5),
+	)
+	assert.Nil(t, err)
+
+	err = interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil)
+	assert.Nil(t, err)
+	assert.True(t, proxied)
+}
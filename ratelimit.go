@@ -0,0 +1,95 @@
+package interserviceclient
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimit limits the rate at which requests are sent to a dependency
+type RateLimit struct {
+	// The sustained number of requests sent per second
+	RequestsPerSecond float64 `yaml:"requestsPerSecond"`
+
+	// The number of requests that may be sent at once after a quiet period. It defaults to 1
+	Burst int `yaml:"burst,omitempty"`
+}
+
+// WithRateLimit limits the requests sent to the dependency to requestsPerSecond, allowing bursts
+// of up to burst requests. Requests over the limit wait for their turn until their context is done
+func WithRateLimit(requestsPerSecond float64, burst int) ClientOption {
+	return func(o *clientOptions) {
+		o.rateLimit = RateLimit{RequestsPerSecond: requestsPerSecond, Burst: burst}
+	}
+}
+
+// rateLimitTransport delays requests that exceed a rate limit using a token bucket
+type rateLimitTransport struct {
+	next  http.RoundTripper
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimitTransport creates a transport that starts with a full bucket
+func newRateLimitTransport(next http.RoundTripper, limit RateLimit) *rateLimitTransport {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimitTransport{
+		next:   next,
+		rate:   limit.RequestsPerSecond,
+		burst:  burst,
+		now:    time.Now,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// RoundTrip waits for the request's turn and sends it
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	wait := t.reserve()
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			t.cancel()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// reserve takes a token for a request, returning how long the request must wait for it
+func (t *rateLimitTransport) reserve() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+	t.last = now
+
+	t.tokens--
+	if t.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-t.tokens / t.rate * float64(time.Second))
+}
+
+// cancel returns the token taken by a request that gave up waiting
+func (t *rateLimitTransport) cancel() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tokens++
+}
@@ -0,0 +1,44 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithRateLimit(20, 2),
+	)
+	assert.Nil(t, err)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+	}
+	// the burst is sent at once and the other two wait 50ms each
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	// requests stop waiting for their turn when their context is done
+	slow, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithRateLimit(0.1, 1),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, interserviceclient.Call(context.Background(), slow, http.MethodGet, "users", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = interserviceclient.Call(ctx, slow, http.MethodGet, "users", nil)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,136 @@
+package interserviceclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/savannahghi/serverutils"
+)
+
+// ErrRegistryClosed is returned when a client is requested from a closed Registry
+var ErrRegistryClosed = errors.New("the client registry is closed")
+
+// Registry builds and holds the clients for the dependencies declared for an environment.
+// Each client is created with the dependency's settings the first time it is requested and is
+// reused after that
+type Registry struct {
+	environment string
+	deps        map[string]Dep
+	opts        []ClientOption
+
+	mu      sync.Mutex
+	clients map[string]*InterServiceClient
+	closed  bool
+}
+
+// NewRegistry creates a registry for the dependencies declared for the environment, which
+// defaults to the environment the service is running in. The options are applied to every
+// client before the settings declared for its dependency. Use WithDepsWatcher for the clients
+// to follow changes to the deps file.
+//
+// Unless a transport is supplied with WithTransport, the clients share a copy of
+// http.DefaultTransport owned by the registry, so that closing the registry does not affect
+// other HTTP clients in the process
+func NewRegistry(config DepsConfig, environment string, opts ...ClientOption) (*Registry, error) {
+	if environment == "" {
+		environment = serverutils.GetRunningEnvironment()
+	}
+
+	deps, err := config.Deps(environment)
+	if err != nil {
+		return nil, err
+	}
+	if problems := validateDeps(environment, deps); len(problems) > 0 {
+		return nil, &DepsValidationError{Problems: problems}
+	}
+
+	options := defaultClientOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.transport == nil {
+		if t, ok := http.DefaultTransport.(*http.Transport); ok {
+			opts = append([]ClientOption{WithTransport(t.Clone())}, opts...)
+		}
+	}
+
+	registry := &Registry{
+		environment: environment,
+		deps:        map[string]Dep{},
+		opts:        opts,
+		clients:     map[string]*InterServiceClient{},
+	}
+	for _, dep := range deps {
+		registry.deps[dep.DepName] = dep
+	}
+	return registry, nil
+}
+
+// Client returns the client for the named dependency, creating it on first use. It returns a
+// *DepNotFoundError when the dependency is not declared for the registry's environment
+func (r *Registry) Client(name string) (*InterServiceClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil, ErrRegistryClosed
+	}
+	if client, ok := r.clients[name]; ok {
+		return client, nil
+	}
+
+	dep, ok := r.deps[name]
+	if !ok {
+		return nil, &DepNotFoundError{Name: name}
+	}
+
+	client, err := NewInterserviceClient(ISCService{
+		Name:       dep.DepName,
+		RootDomain: dep.DepRootDomain,
+		Endpoints:  dep.DepEndpoints,
+	}, append(append([]ClientOption{}, r.opts...), dep.ClientOptions()...)...)
+	if err != nil {
+		return nil, fmt.Errorf("can't create the client for %s in the %s environment: %w", name, r.environment, err)
+	}
+
+	r.clients[name] = client
+	return client, nil
+}
+
+// Environment returns the environment whose dependencies are in the registry
+func (r *Registry) Environment() string {
+	return r.environment
+}
+
+// Dependencies returns the dependencies in the registry sorted by name
+func (r *Registry) Dependencies() []Dep {
+	deps := []Dep{}
+	for _, dep := range r.deps {
+		deps = append(deps, dep)
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		return deps[i].DepName < deps[j].DepName
+	})
+	return deps
+}
+
+// Close closes the idle connections of the clients that were created. Clients can not be
+// requested from the registry after it is closed
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	for _, client := range r.clients {
+		client.CloseIdleConnections()
+	}
+	r.clients = map[string]*InterServiceClient{}
+	return nil
+}
@@ -0,0 +1,144 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	var closed int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Tenant", r.Header.Get("X-Tenant"))
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			atomic.AddInt32(&closed, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	config := interserviceclient.DepsConfig{Environments: map[string][]interserviceclient.Dep{
+		"sandbox": {
+			{DepName: "profile", DepRootDomain: srv.URL, Headers: map[string]string{"X-Tenant": "bewell"}},
+			{DepName: "engagement", DepRootDomain: srv.URL},
+		},
+		"staging": {
+			{DepName: "mailgun", DepRootDomain: "https://mailgun.example.com"},
+		},
+	}}
+
+	registry, err := interserviceclient.NewRegistry(
+		config,
+		"sandbox",
+		interserviceclient.WithTransport(&http.Transport{}),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "sandbox", registry.Environment())
+
+	deps := registry.Dependencies()
+	assert.Len(t, deps, 2)
+	assert.Equal(t, "engagement", deps[0].DepName)
+	assert.Equal(t, "profile", deps[1].DepName)
+
+	profile, err := registry.Client("profile")
+	assert.Nil(t, err)
+	again, err := registry.Client("profile")
+	assert.Nil(t, err)
+	assert.Same(t, profile, again)
+
+	resp, err := profile.DoRequest(context.Background(), http.MethodGet, "users", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "bewell", resp.Header.Get("X-Tenant"))
+	assert.Nil(t, resp.Body.Close())
+
+	_, err = registry.Client("mailgun")
+	var notFound *interserviceclient.DepNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+
+	assert.Nil(t, registry.Close())
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&closed) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	_, err = registry.Client("profile")
+	assert.True(t, errors.Is(err, interserviceclient.ErrRegistryClosed))
+}
+
+func TestNewRegistry_Errors(t *testing.T) {
+	_, err := interserviceclient.NewRegistry(interserviceclient.DepsConfig{}, "sandbox")
+	assert.NotNil(t, err)
+
+	_, err = interserviceclient.NewRegistry(interserviceclient.DepsConfig{Environments: map[string][]interserviceclient.Dep{
+		"staging": {{DepName: "profile"}},
+	}}, "")
+	var validationErr *interserviceclient.DepsValidationError
+	assert.True(t, errors.As(err, &validationErr))
+}
+
+func TestRegistry_CloseKeepsDefaultTransport(t *testing.T) {
+	var closed int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			atomic.AddInt32(&closed, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	// a client outside the registry keeps an idle connection in http.DefaultTransport
+	other, err := interserviceclient.NewInterserviceClient(interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL})
+	assert.Nil(t, err)
+	assert.Nil(t, interserviceclient.Call(context.Background(), other, http.MethodGet, "users", nil))
+
+	registry, err := interserviceclient.NewRegistry(interserviceclient.DepsConfig{Environments: map[string][]interserviceclient.Dep{
+		"sandbox": {{DepName: "profile", DepRootDomain: srv.URL}},
+	}}, "sandbox")
+	assert.Nil(t, err)
+	profile, err := registry.Client("profile")
+	assert.Nil(t, err)
+	assert.Nil(t, interserviceclient.Call(context.Background(), profile, http.MethodGet, "users", nil))
+
+	// only the registry's connection is closed
+	assert.Nil(t, registry.Close())
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&closed) == 1
+	}, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&closed))
+
+	other.CloseIdleConnections()
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&closed) == 2
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestNewRegistry_DefaultHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Foo", r.Header.Get("X-Foo"))
+	}))
+	defer srv.Close()
+
+	registry, err := interserviceclient.NewRegistry(interserviceclient.DepsConfig{Environments: map[string][]interserviceclient.Dep{
+		"staging": {{DepName: "profile", DepRootDomain: srv.URL}},
+	}}, "staging", interserviceclient.WithDefaultHeaders(map[string]string{"X-Foo": "bar"}))
+	assert.Nil(t, err)
+	defer registry.Close()
+
+	profile, err := registry.Client("profile")
+	assert.Nil(t, err)
+	resp, err := profile.DoRequest(context.Background(), http.MethodGet, "users", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "bar", resp.Header.Get("X-Foo"))
+	assert.Nil(t, resp.Body.Close())
+}
@@ -0,0 +1,75 @@
+package interserviceclient
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// maxRequestIDLength is the longest request ID accepted from a caller
+const maxRequestIDLength = 128
+
+// ContextWithRequestID returns a copy of the context that carries the request ID
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, RequestIDContextKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by the context, if any
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(RequestIDContextKey).(string)
+	return requestID, ok && requestID != ""
+}
+
+// NewRequestID generates a new request ID
+func NewRequestID() string {
+	return uuid.NewString()
+}
+
+// LoggerWithRequestID returns a logger that adds the request ID on the context, if any, to its entries
+func LoggerWithRequestID(ctx context.Context, logger logrus.FieldLogger) logrus.FieldLogger {
+	if requestID, ok := RequestIDFromContext(ctx); ok {
+		return logger.WithField("request_id", requestID)
+	}
+	return logger
+}
+
+// RequestIDMiddleware reads the request ID sent by the caller, or generates one when it is absent
+// or malformed, puts it on the request context and echoes it in the response headers
+func RequestIDMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				requestID := incomingRequestID(r)
+
+				w.Header().Set(RequestIDHeader, requestID)
+				ctx := ContextWithRequestID(r.Context(), requestID)
+
+				next.ServeHTTP(w, r.WithContext(ctx))
+			})
+	}
+}
+
+// incomingRequestID returns a valid request ID sent by the caller or a new one
+func incomingRequestID(r *http.Request) string {
+	for _, header := range []string{RequestIDHeader, CorrelationIDHeader} {
+		if requestID := r.Header.Get(header); isValidRequestID(requestID) {
+			return requestID
+		}
+	}
+	return NewRequestID()
+}
+
+// isValidRequestID checks that a request ID is safe to log and forward
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:    "uses the caller's request ID",
+			headers: map[string]string{interserviceclient.RequestIDHeader: "abc-123"},
+			want:    "abc-123",
+		},
+		{
+			name:    "falls back to the correlation ID",
+			headers: map[string]string{interserviceclient.CorrelationIDHeader: "corr-456"},
+			want:    "corr-456",
+		},
+		{
+			name: "generates a request ID when absent",
+		},
+		{
+			name:    "replaces a malformed request ID",
+			headers: map[string]string{interserviceclient.RequestIDHeader: "bad id\n"},
+		},
+		{
+			name:    "replaces an overly long request ID",
+			headers: map[string]string{interserviceclient.RequestIDHeader: strings.Repeat("a", 200)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = interserviceclient.RequestIDFromContext(r.Context())
+			})
+			h := interserviceclient.RequestIDMiddleware()(next)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, req)
+
+			assert.NotEmpty(t, got)
+			assert.Equal(t, got, rw.Header().Get(interserviceclient.RequestIDHeader))
+			if tt.want != "" {
+				assert.Equal(t, tt.want, got)
+			} else {
+				assert.Len(t, got, 36)
+			}
+		})
+	}
+}
+
+func TestInterServiceClient_PropagatesRequestID(t *testing.T) {
+	var got string
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(interserviceclient.RequestIDHeader)
+	})
+
+	ctx := interserviceclient.ContextWithRequestID(context.Background(), "abc-123")
+	err := interserviceclient.Call(ctx, client, http.MethodGet, "users", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "abc-123", got)
+
+	err = interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil)
+	assert.Nil(t, err)
+	assert.Len(t, got, 36)
+}
+
+func TestLoggerWithRequestID(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+
+	ctx := interserviceclient.ContextWithRequestID(context.Background(), "abc-123")
+	interserviceclient.LoggerWithRequestID(ctx, logger).Info("handled")
+	assert.Equal(t, "abc-123", hook.LastEntry().Data["request_id"])
+
+	interserviceclient.LoggerWithRequestID(context.Background(), logger).Info("handled")
+	assert.Equal(t, logrus.Fields{}, hook.LastEntry().Data)
+}
@@ -0,0 +1,157 @@
+package interserviceclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RequestOption customises a single inter service request
+type RequestOption func(*requestOptions)
+
+// requestOptions holds the settings for a single inter service request
+type requestOptions struct {
+	query          url.Values
+	headers        http.Header
+	timeout        time.Duration
+	expectedStatus []int
+	contentType    string
+	bodyReader     io.Reader
+	route          string
+	operation      string
+}
+
+// newRequestOptions applies the supplied options to the default request settings
+func newRequestOptions(opts []RequestOption) *requestOptions {
+	options := &requestOptions{
+		query:       url.Values{},
+		headers:     http.Header{},
+		contentType: "application/json",
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithQuery adds query parameters to the request URL. They are merged with any query
+// string that is already part of the request path
+func WithQuery(query url.Values) RequestOption {
+	return func(o *requestOptions) {
+		for key, values := range query {
+			for _, value := range values {
+				o.query.Add(key, value)
+			}
+		}
+	}
+}
+
+// WithQueryParam adds a single query parameter to the request URL
+func WithQueryParam(key string, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.query.Add(key, value)
+	}
+}
+
+// WithHeader sets an extra header on the request. It can not override the `Authorization` header
+func WithHeader(key string, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.headers.Set(key, value)
+	}
+}
+
+// WithRequestTimeout sets a time limit for this request, including reading the response body.
+// The client wide timeout still applies
+func WithRequestTimeout(timeout time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = timeout
+	}
+}
+
+// ExpectStatus sets the status codes that Do and Call treat as a success.
+// By default any 2xx status code is a success
+func ExpectStatus(statusCodes ...int) RequestOption {
+	return func(o *requestOptions) {
+		o.expectedStatus = append(o.expectedStatus, statusCodes...)
+	}
+}
+
+// WithContentType sets the content type of the request body. The body is still encoded as JSON,
+// which makes this useful for JSON based media types such as `application/merge-patch+json`
+func WithContentType(contentType string) RequestOption {
+	return func(o *requestOptions) {
+		o.contentType = contentType
+	}
+}
+
+// WithBodyReader sends the contents of the reader as the request body, as is, instead of
+// encoding the body argument as JSON. The body is streamed rather than buffered in memory
+func WithBodyReader(body io.Reader, contentType string) RequestOption {
+	return func(o *requestOptions) {
+		o.bodyReader = body
+		o.contentType = contentType
+	}
+}
+
+// withTimeout returns a context bounded by the request timeout, if one is set
+func (o *requestOptions) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, o.timeout)
+}
+
+// cancelOnCloseBody releases the resources of a per request timeout once the response body is closed
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the response body and cancels the request context
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// requestIdentityKey is the context key of the identity of the caller making a request
+type requestIdentityKey struct{}
+
+// requestIdentity returns a digest of the credentials and headers that are specific to the
+// caller of a request: a token from the client's token source and the per request headers.
+// It is empty when the request is made with the client's own identity, which every caller
+// of the client shares
+func requestIdentity(tokenSource bool, token string, options *requestOptions) string {
+	var b strings.Builder
+	if tokenSource && token != "" {
+		b.WriteString("Authorization: Bearer " + token + "\n")
+	}
+
+	names := make([]string, 0, len(options.headers))
+	for name := range options.headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString(name + ": " + strings.Join(options.headers[name], ",") + "\n")
+	}
+
+	if b.Len() == 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// identityFromRequest returns the identity of the caller that made the request, see
+// requestIdentity
+func identityFromRequest(req *http.Request) string {
+	identity, _ := req.Context().Value(requestIdentityKey{}).(string)
+	return identity
+}
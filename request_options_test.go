@@ -0,0 +1,78 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterServiceClient_DoRequest(t *testing.T) {
+	ctx := context.Background()
+
+	var got *http.Request
+	var gotBody []byte
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	resp, err := client.DoRequest(
+		ctx,
+		http.MethodGet,
+		"users?active=true",
+		map[string]string{"ignored": "for GET requests"},
+		interserviceclient.WithQuery(url.Values{"role": []string{"admin", "staff"}}),
+		interserviceclient.WithQueryParam("page", "2"),
+		interserviceclient.WithHeader("X-Tenant", "bewell"),
+		interserviceclient.WithHeader("Authorization", "Bearer not-allowed"),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, resp.Body.Close())
+
+	assert.Equal(t, "/users", got.URL.Path)
+	assert.Equal(t, "true", got.URL.Query().Get("active"))
+	assert.Equal(t, []string{"admin", "staff"}, got.URL.Query()["role"])
+	assert.Equal(t, "2", got.URL.Query().Get("page"))
+	assert.Equal(t, "bewell", got.Header.Get("X-Tenant"))
+	assert.NotEqual(t, "Bearer not-allowed", got.Header.Get("Authorization"))
+	assert.Empty(t, gotBody)
+
+	resp, err = client.DoRequest(
+		ctx,
+		http.MethodPatch,
+		"users/1",
+		map[string]string{"name": "test"},
+		interserviceclient.WithContentType("application/merge-patch+json"),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, resp.Body.Close())
+
+	assert.Equal(t, "application/merge-patch+json", got.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"name":"test"}`, string(gotBody))
+}
+
+func TestInterServiceClient_DoRequestTimeout(t *testing.T) {
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+
+	err := interserviceclient.Call(
+		context.Background(),
+		client,
+		http.MethodGet,
+		"slow",
+		nil,
+		interserviceclient.WithRequestTimeout(20*time.Millisecond),
+	)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,360 @@
+package interserviceclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// defaultResolverPollInterval is how often a FileResolver checks its file for changes
+const defaultResolverPollInterval = 5 * time.Second
+
+// Resolver finds the endpoints at which a dependency serves requests. A client with a resolver
+// consults it for every request, so implementations that are slow should be cached
+type Resolver interface {
+	Resolve(ctx context.Context, service string) ([]Endpoint, error)
+}
+
+// ResolverFunc adapts an ordinary function to a Resolver
+type ResolverFunc func(ctx context.Context, service string) ([]Endpoint, error)
+
+// Resolve calls f(ctx, service)
+func (f ResolverFunc) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	return f(ctx, service)
+}
+
+// WithResolver resolves the endpoints of the dependency at request time, instead of using the
+// static root domain and endpoints. Results are cached for the ttl when it is positive
+func WithResolver(resolver Resolver, ttl time.Duration) ClientOption {
+	if ttl > 0 {
+		resolver = NewCachingResolver(resolver, ttl)
+	}
+	return func(o *clientOptions) {
+		o.resolver = resolver
+	}
+}
+
+// StaticResolver resolves dependencies to a fixed set of endpoints keyed by dependency name
+type StaticResolver map[string][]Endpoint
+
+// NewStaticResolver creates a resolver for the dependencies declared in a deps file
+func NewStaticResolver(deps []Dep) StaticResolver {
+	resolver := StaticResolver{}
+	for _, dep := range deps {
+		endpoints := dep.DepEndpoints
+		if dep.DepRootDomain != "" {
+			endpoints = withRootDomain(dep.DepRootDomain, endpoints)
+		}
+		resolver[dep.DepName] = endpoints
+	}
+	return resolver
+}
+
+// Resolve returns the endpoints of the dependency
+func (r StaticResolver) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	endpoints, ok := r[service]
+	if !ok {
+		return nil, fmt.Errorf("no endpoints are known for %s", service)
+	}
+	return endpoints, nil
+}
+
+// SRVLookup looks up DNS SRV records. It is implemented by *net.Resolver
+type SRVLookup interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// DNSSRVOption configures a DNSSRVResolver
+type DNSSRVOption func(*DNSSRVResolver)
+
+// WithSRVScheme sets the URL scheme of the resolved endpoints. The default is `https`
+func WithSRVScheme(scheme string) DNSSRVOption {
+	return func(r *DNSSRVResolver) {
+		r.scheme = scheme
+	}
+}
+
+// WithSRVProtocol sets the protocol of the SRV records. The default is `tcp`
+func WithSRVProtocol(proto string) DNSSRVOption {
+	return func(r *DNSSRVResolver) {
+		r.proto = proto
+	}
+}
+
+// WithSRVLookup sets how the SRV records are looked up. The default is net.DefaultResolver
+func WithSRVLookup(lookup SRVLookup) DNSSRVOption {
+	return func(r *DNSSRVResolver) {
+		r.lookup = lookup
+	}
+}
+
+// DNSSRVResolver resolves a dependency using the `_<name>._tcp.<domain>` SRV records.
+// Only the records with the highest priority i.e the lowest priority value are used, and the
+// record weights become the endpoint weights
+type DNSSRVResolver struct {
+	domain string
+	scheme string
+	proto  string
+	lookup SRVLookup
+}
+
+// NewDNSSRVResolver creates a resolver for the SRV records under the domain
+// e.g `svc.cluster.local`
+func NewDNSSRVResolver(domain string, opts ...DNSSRVOption) *DNSSRVResolver {
+	r := &DNSSRVResolver{
+		domain: domain,
+		scheme: "https",
+		proto:  "tcp",
+		lookup: net.DefaultResolver,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Resolve looks up the endpoints of the dependency
+func (r *DNSSRVResolver) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	_, records, err := r.lookup.LookupSRV(ctx, service, r.proto, r.domain)
+	if err != nil {
+		return nil, fmt.Errorf("can't look up SRV records for %s: %w", service, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no SRV records found for %s", service)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Priority < records[j].Priority
+	})
+
+	endpoints := []Endpoint{}
+	for _, record := range records {
+		if record.Priority != records[0].Priority {
+			break
+		}
+		host := strings.TrimSuffix(record.Target, ".")
+		endpoints = append(endpoints, Endpoint{
+			URL:    fmt.Sprintf("%s://%s", r.scheme, net.JoinHostPort(host, strconv.Itoa(int(record.Port)))),
+			Weight: int(record.Weight),
+		})
+	}
+	return endpoints, nil
+}
+
+// FileResolverOption configures a FileResolver
+type FileResolverOption func(*FileResolver)
+
+// WithResolverPollInterval sets how often the file is checked for changes. It must be
+// positive. The default is 5 seconds
+func WithResolverPollInterval(interval time.Duration) FileResolverOption {
+	return func(r *FileResolver) {
+		r.interval = interval
+	}
+}
+
+// FileResolver resolves dependencies using a local JSON or YAML registry file that maps each
+// dependency name to its endpoints e.g
+//
+//	profile:
+//	  - url: https://profile.bewell.co.ke
+//	  - url: https://profile-eu.bewell.co.ke
+//	    weight: 2
+//
+// The file is reloaded when it changes. A change that can not be read keeps the endpoints
+// that were last loaded
+type FileResolver struct {
+	path     string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	endpoints map[string][]Endpoint
+	modTime   time.Time
+	err       error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewFileResolver loads the registry file and watches it for changes until Close is called
+func NewFileResolver(path string, opts ...FileResolverOption) (*FileResolver, error) {
+	r := &FileResolver{
+		path:     path,
+		interval: defaultResolverPollInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.interval <= 0 {
+		return nil, fmt.Errorf("invalid resolver poll interval %s", r.interval)
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	go r.watch()
+	return r, nil
+}
+
+// Resolve returns the endpoints of the dependency in the latest version of the file
+func (r *FileResolver) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	endpoints, ok := r.endpoints[service]
+	if !ok {
+		return nil, fmt.Errorf("no endpoints are registered for %s in %s", service, r.path)
+	}
+	return endpoints, nil
+}
+
+// Reload reads the registry file
+func (r *FileResolver) Reload() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return r.setErr(fmt.Errorf("can't read registry file: %w", err))
+	}
+
+	data, err := os.ReadFile(filepath.Clean(r.path))
+	if err != nil {
+		return r.setErr(fmt.Errorf("can't read registry file: %w", err))
+	}
+
+	endpoints := map[string][]Endpoint{}
+	if err := yaml.Unmarshal(data, &endpoints); err != nil {
+		return r.setErr(fmt.Errorf("can't unmarshal registry file %s: %w", r.path, err))
+	}
+	for service, serviceEndpoints := range endpoints {
+		if _, err := parseEndpoints(serviceEndpoints); err != nil {
+			return r.setErr(fmt.Errorf("invalid endpoints for %s in %s: %w", service, r.path, err))
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endpoints = endpoints
+	r.modTime = info.ModTime()
+	r.err = nil
+	return nil
+}
+
+// Err returns the error from the latest attempt to reload the file, if it failed
+func (r *FileResolver) Err() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.err
+}
+
+// setErr records a failed reload
+func (r *FileResolver) setErr(err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.err = err
+	return err
+}
+
+// watch reloads the file whenever its modification time changes
+func (r *FileResolver) watch() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(r.path)
+		if err != nil {
+			_ = r.setErr(fmt.Errorf("can't read registry file: %w", err))
+			continue
+		}
+
+		r.mu.RLock()
+		changed := !info.ModTime().Equal(r.modTime)
+		r.mu.RUnlock()
+
+		if changed {
+			_ = r.Reload()
+		}
+	}
+}
+
+// Close stops watching the file
+func (r *FileResolver) Close() error {
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+	<-r.done
+	return nil
+}
+
+// cachedEndpoints is a resolution held by a caching resolver
+type cachedEndpoints struct {
+	endpoints []Endpoint
+	expires   time.Time
+}
+
+// CachingResolver caches the results of another resolver. When a resolution fails after the
+// cached result has expired, the expired result is used until the resolver recovers
+type CachingResolver struct {
+	next Resolver
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedEndpoints
+}
+
+// NewCachingResolver caches the results of the resolver for the ttl
+func NewCachingResolver(resolver Resolver, ttl time.Duration) *CachingResolver {
+	return &CachingResolver{
+		next:    resolver,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]cachedEndpoints{},
+	}
+}
+
+// Resolve returns the cached endpoints of the dependency, resolving them when they have expired
+func (r *CachingResolver) Resolve(ctx context.Context, service string) ([]Endpoint, error) {
+	r.mu.Lock()
+	cached, ok := r.entries[service]
+	r.mu.Unlock()
+
+	if ok && r.now().Before(cached.expires) {
+		return cached.endpoints, nil
+	}
+
+	endpoints, err := r.next.Resolve(ctx, service)
+	if err != nil {
+		if ok {
+			return cached.endpoints, nil
+		}
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.entries[service] = cachedEndpoints{endpoints: endpoints, expires: r.now().Add(r.ttl)}
+	r.mu.Unlock()
+
+	return endpoints, nil
+}
@@ -0,0 +1,210 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+// srvLookupFunc adapts a function to an interserviceclient.SRVLookup
+type srvLookupFunc func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+
+func (f srvLookupFunc) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	return f(ctx, service, proto, name)
+}
+
+func TestInterServiceClient_Resolver(t *testing.T) {
+	old := newEndpointServer(t, http.StatusOK)
+	moved := newEndpointServer(t, http.StatusOK)
+
+	current := old.URL
+	resolutions := 0
+	resolver := interserviceclient.ResolverFunc(func(ctx context.Context, service string) ([]interserviceclient.Endpoint, error) {
+		resolutions++
+		assert.Equal(t, "profile", service)
+		return []interserviceclient.Endpoint{{URL: current}}, nil
+	})
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile"},
+		interserviceclient.WithResolver(resolver, 0),
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+	current = moved.URL
+	assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+
+	assert.Equal(t, 1, old.count())
+	assert.Equal(t, 1, moved.count())
+	assert.Equal(t, 2, resolutions)
+}
+
+func TestInterServiceClient_ResolverFailure(t *testing.T) {
+	resolver := interserviceclient.ResolverFunc(func(ctx context.Context, service string) ([]interserviceclient.Endpoint, error) {
+		return nil, errors.New("registry unavailable")
+	})
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile"},
+		interserviceclient.WithResolver(resolver, time.Minute),
+	)
+	assert.Nil(t, err)
+
+	err = interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil)
+	assert.NotNil(t, err)
+}
+
+func TestCachingResolver(t *testing.T) {
+	resolutions := 0
+	fail := false
+	resolver := interserviceclient.NewCachingResolver(
+		interserviceclient.ResolverFunc(func(ctx context.Context, service string) ([]interserviceclient.Endpoint, error) {
+			resolutions++
+			if fail {
+				return nil, errors.New("registry unavailable")
+			}
+			return []interserviceclient.Endpoint{{URL: "https://profile.example.com"}}, nil
+		}),
+		20*time.Millisecond,
+	)
+
+	for i := 0; i < 3; i++ {
+		endpoints, err := resolver.Resolve(context.Background(), "profile")
+		assert.Nil(t, err)
+		assert.Len(t, endpoints, 1)
+	}
+	assert.Equal(t, 1, resolutions)
+
+	// expired results are used while the resolver is failing
+	time.Sleep(30 * time.Millisecond)
+	fail = true
+	endpoints, err := resolver.Resolve(context.Background(), "profile")
+	assert.Nil(t, err)
+	assert.Len(t, endpoints, 1)
+	assert.Equal(t, 2, resolutions)
+
+	_, err = resolver.Resolve(context.Background(), "engagement")
+	assert.NotNil(t, err)
+}
+
+func TestStaticResolver(t *testing.T) {
+	resolver := interserviceclient.NewStaticResolver([]interserviceclient.Dep{{
+		DepName:       "profile",
+		DepRootDomain: "https://profile.example.com",
+		DepEndpoints:  []interserviceclient.Endpoint{{URL: "https://profile-eu.example.com"}},
+	}})
+
+	endpoints, err := resolver.Resolve(context.Background(), "profile")
+	assert.Nil(t, err)
+	assert.Equal(t, []interserviceclient.Endpoint{
+		{URL: "https://profile.example.com"},
+		{URL: "https://profile-eu.example.com"},
+	}, endpoints)
+
+	_, err = resolver.Resolve(context.Background(), "engagement")
+	assert.NotNil(t, err)
+}
+
+func TestDNSSRVResolver(t *testing.T) {
+	lookup := srvLookupFunc(func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		assert.Equal(t, "profile", service)
+		assert.Equal(t, "tcp", proto)
+		assert.Equal(t, "svc.cluster.local", name)
+		return "", []*net.SRV{
+			{Target: "fallback.svc.cluster.local.", Port: 8080, Priority: 20, Weight: 1},
+			{Target: "a.svc.cluster.local.", Port: 8080, Priority: 10, Weight: 3},
+			{Target: "b.svc.cluster.local.", Port: 8081, Priority: 10, Weight: 1},
+		}, nil
+	})
+
+	resolver := interserviceclient.NewDNSSRVResolver(
+		"svc.cluster.local",
+		interserviceclient.WithSRVLookup(lookup),
+		interserviceclient.WithSRVScheme("http"),
+	)
+
+	endpoints, err := resolver.Resolve(context.Background(), "profile")
+	assert.Nil(t, err)
+	assert.Equal(t, []interserviceclient.Endpoint{
+		{URL: "http://a.svc.cluster.local:8080", Weight: 3},
+		{URL: "http://b.svc.cluster.local:8081", Weight: 1},
+	}, endpoints)
+}
+
+func TestFileResolver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	write := func(content string, modTime time.Time) {
+		assert.Nil(t, os.WriteFile(path, []byte(content), 0o600))
+		assert.Nil(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	start := time.Now().Add(-time.Hour)
+	write("profile:\n  - url: https://profile.example.com\n", start)
+
+	resolver, err := interserviceclient.NewFileResolver(path, interserviceclient.WithResolverPollInterval(5*time.Millisecond))
+	assert.Nil(t, err)
+	defer resolver.Close()
+
+	endpoints, err := resolver.Resolve(context.Background(), "profile")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://profile.example.com", endpoints[0].URL)
+
+	// JSON registries are supported and changes are picked up
+	write(`{"profile": [{"url": "https://profile-eu.example.com", "weight": 2}]}`, start.Add(time.Minute))
+	assert.Eventually(t, func() bool {
+		endpoints, err := resolver.Resolve(context.Background(), "profile")
+		return err == nil && endpoints[0].URL == "https://profile-eu.example.com"
+	}, time.Second, 5*time.Millisecond)
+
+	// an invalid change keeps the last good registry
+	write("profile: [{url: not-a-url}]", start.Add(2*time.Minute))
+	assert.Eventually(t, func() bool {
+		return resolver.Err() != nil
+	}, time.Second, 5*time.Millisecond)
+	endpoints, err = resolver.Resolve(context.Background(), "profile")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, endpoints[0].Weight)
+
+	_, err = interserviceclient.NewFileResolver(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.NotNil(t, err)
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		_, err = interserviceclient.NewFileResolver(path, interserviceclient.WithResolverPollInterval(interval))
+		assert.NotNil(t, err)
+	}
+}
+
+func TestInterServiceClient_DNSResolvedEndpoints(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	host, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	assert.Nil(t, err)
+
+	lookup := srvLookupFunc(func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		p, _ := net.LookupPort("tcp", port)
+		return "", []*net.SRV{{Target: host, Port: uint16(p)}}, nil
+	})
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile"},
+		interserviceclient.WithResolver(interserviceclient.NewDNSSRVResolver(
+			"svc.cluster.local",
+			interserviceclient.WithSRVLookup(lookup),
+			interserviceclient.WithSRVScheme("http"),
+		), time.Minute),
+	)
+	assert.Nil(t, err)
+
+	assert.Nil(t, interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil))
+}
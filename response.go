@@ -0,0 +1,117 @@
+package interserviceclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Do performs an inter service request and decodes the JSON response body into Resp.
+// Unless expected status codes are supplied using ExpectStatus, any 2xx status code is treated
+// as a success. Any other status code results in an *ISCError. The response body is always closed.
+func Do[Resp any](
+	ctx context.Context,
+	client Client,
+	method string,
+	path string,
+	body interface{},
+	opts ...RequestOption,
+) (Resp, error) {
+	var result Resp
+	if isNilClient(client) {
+		return result, fmt.Errorf("nil ISC client")
+	}
+
+	resp, err := client.DoRequest(ctx, method, path, body, opts...)
+	if err != nil {
+		return result, transportError(client.DependencyName(), method, path, err)
+	}
+
+	return decodeResponse[Resp](client.DependencyName(), resp, newRequestOptions(opts).expectedStatus...)
+}
+
+// Call performs an inter service request whose response body is of no interest to the caller.
+// The status code is checked in the same way as Do and the response body is always closed.
+func Call(
+	ctx context.Context,
+	client Client,
+	method string,
+	path string,
+	body interface{},
+	opts ...RequestOption,
+) error {
+	if isNilClient(client) {
+		return fmt.Errorf("nil ISC client")
+	}
+
+	resp, err := client.DoRequest(ctx, method, path, body, opts...)
+	if err != nil {
+		return transportError(client.DependencyName(), method, path, err)
+	}
+
+	_, err = readResponse(client.DependencyName(), resp, newRequestOptions(opts).expectedStatus...)
+	return err
+}
+
+// DecodeResponse checks the status code of an inter service response and decodes its JSON
+// body into Resp. An empty body decodes to the zero value of Resp. The response body is
+// always closed.
+func DecodeResponse[Resp any](resp *http.Response, expectedStatus ...int) (Resp, error) {
+	return decodeResponse[Resp]("", resp, expectedStatus...)
+}
+
+// decodeResponse decodes a response from the named dependency
+func decodeResponse[Resp any](service string, resp *http.Response, expectedStatus ...int) (Resp, error) {
+	var result Resp
+
+	data, err := readResponse(service, resp, expectedStatus...)
+	if err != nil {
+		return result, err
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return result, nil
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, fmt.Errorf("can't unmarshal response body from JSON: %w", err)
+	}
+
+	return result, nil
+}
+
+// readResponse reads and closes the response body, returning an *ISCError when the status code
+// is not expected
+func readResponse(service string, resp *http.Response, expectedStatus ...int) ([]byte, error) {
+	if resp == nil {
+		return nil, fmt.Errorf("nil response")
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read response body: %w", err)
+	}
+
+	if !isExpectedStatus(resp.StatusCode, expectedStatus) {
+		return nil, newStatusError(service, resp, data)
+	}
+
+	return data, nil
+}
+
+// isExpectedStatus checks a status code against the expected ones, defaulting to any 2xx status
+func isExpectedStatus(statusCode int, expectedStatus []int) bool {
+	if len(expectedStatus) == 0 {
+		return statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices
+	}
+	for _, expected := range expectedStatus {
+		if statusCode == expected {
+			return true
+		}
+	}
+	return false
+}
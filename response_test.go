@@ -0,0 +1,132 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) *interserviceclient.InterServiceClient {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	client, err := interserviceclient.NewInterserviceClient(interserviceclient.ISCService{
+		Name:       "test",
+		RootDomain: srv.URL,
+	})
+	assert.Nil(t, err)
+	return client
+}
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+
+	type result struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name           string
+		status         int
+		body           string
+		expectedStatus []int
+		want           result
+		wantErr        bool
+		wantStatus     int
+	}{
+		{
+			name:   "success: decodes a 2xx response",
+			status: http.StatusOK,
+			body:   `{"name":"profile"}`,
+			want:   result{Name: "profile"},
+		},
+		{
+			name:           "success: decodes an explicitly expected status",
+			status:         http.StatusCreated,
+			body:           `{"name":"created"}`,
+			expectedStatus: []int{http.StatusCreated},
+			want:           result{Name: "created"},
+		},
+		{
+			name:   "success: empty body decodes to the zero value",
+			status: http.StatusNoContent,
+		},
+		{
+			name:           "fail: unexpected status code",
+			status:         http.StatusOK,
+			body:           `{"name":"profile"}`,
+			expectedStatus: []int{http.StatusCreated},
+			wantErr:        true,
+			wantStatus:     http.StatusOK,
+		},
+		{
+			name:       "fail: error status code",
+			status:     http.StatusBadRequest,
+			body:       `{"error":"bad request"}`,
+			wantErr:    true,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "fail: invalid JSON",
+			status:  http.StatusOK,
+			body:    `not json`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			})
+
+			got, err := interserviceclient.Do[result](
+				ctx,
+				client,
+				http.MethodPost,
+				"users",
+				nil,
+				interserviceclient.ExpectStatus(tt.expectedStatus...),
+			)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Do() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantStatus != 0 {
+				var iscErr *interserviceclient.ISCError
+				if !errors.As(err, &iscErr) {
+					t.Errorf("Do() error = %v, expected an *ISCError", err)
+					return
+				}
+				assert.Equal(t, tt.wantStatus, iscErr.StatusCode)
+				assert.Equal(t, "/users", iscErr.Path)
+				assert.Equal(t, http.MethodPost, iscErr.Method)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDo_NilClient(t *testing.T) {
+	_, err := interserviceclient.Do[string](context.Background(), nil, http.MethodGet, "users", nil)
+	assert.NotNil(t, err)
+}
+
+func TestCall(t *testing.T) {
+	ctx := context.Background()
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`"ok"`))
+	})
+
+	assert.Nil(t, interserviceclient.Call(ctx, client, http.MethodPost, "ok", nil))
+	assert.NotNil(t, interserviceclient.Call(ctx, client, http.MethodPost, "fail", nil))
+}
@@ -0,0 +1,172 @@
+package interserviceclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultInitialBackoff is the wait before the first retry of a request
+	defaultInitialBackoff = 100 * time.Millisecond
+
+	// defaultMaxBackoff is the longest wait between retries of a request
+	defaultMaxBackoff = 2 * time.Second
+)
+
+// RetryPolicy describes how failed requests are retried. Requests are retried after connection
+// errors and 429, 502, 503 and 504 responses, waiting longer after each attempt
+type RetryPolicy struct {
+	// The number of times a request is made, including the first attempt. A request is not
+	// retried when it is one or less
+	MaxAttempts int `yaml:"maxAttempts"`
+
+	// The wait before the first retry, which doubles for each retry after it. It defaults to
+	// 100 milliseconds
+	InitialBackoff time.Duration `yaml:"initialBackoff,omitempty"`
+
+	// The longest wait between retries, including waits requested by the dependency using the
+	// Retry-After header. It defaults to 2 seconds
+	MaxBackoff time.Duration `yaml:"maxBackoff,omitempty"`
+}
+
+// backoff returns the wait before a retry, with up to half of it added at random so that
+// clients that failed together do not retry together
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	initial, max := p.InitialBackoff, p.MaxBackoff
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+	if max <= 0 {
+		max = defaultMaxBackoff
+	}
+
+	wait := initial
+	for i := 1; i < retry && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+	// #nosec G404 jitter does not need a secure random number
+	return wait + time.Duration(rand.Int63n(int64(wait)/2+1))
+}
+
+// maxBackoff returns the longest wait between retries
+func (p RetryPolicy) maxBackoff() time.Duration {
+	if p.MaxBackoff <= 0 {
+		return defaultMaxBackoff
+	}
+	return p.MaxBackoff
+}
+
+// WithRetryPolicy retries failed requests following the policy. Only requests that are
+// idempotent, or that failed before reaching the dependency, are retried
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(o *clientOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+// retryTransport retries failed requests with a backoff
+type retryTransport struct {
+	next    http.RoundTripper
+	policy  RetryPolicy
+	metrics *clientMetrics
+}
+
+// RoundTrip makes the request, retrying it while it fails and attempts remain
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("can't replay request body: %w", err)
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.next.RoundTrip(attemptReq)
+
+		reason := retryReason(resp, err)
+		if reason == "" || attempt >= t.policy.MaxAttempts || !canRetry(req, err) {
+			return resp, err
+		}
+
+		wait := t.policy.backoff(attempt)
+		if retryAfter, ok := parseRetryAfter(resp); ok {
+			wait = retryAfter
+			if wait > t.policy.maxBackoff() {
+				return resp, err
+			}
+		}
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < wait {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		t.metrics.recordRetry(req.Context(), reason)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryReason describes why an attempt may be retried, returning an empty string when it
+// should not be
+func retryReason(resp *http.Response, err error) string {
+	if err != nil {
+		return "connection_error"
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return "throttled"
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return "server_error"
+	}
+	return ""
+}
+
+// canRetry checks whether a failed request may be made again. Requests that may have been
+// processed by the dependency are only repeated when they are idempotent
+func canRetry(req *http.Request, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if isIdempotent(req.Method) {
+		return true
+	}
+
+	var opErr *net.OpError
+	return err != nil && errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// parseRetryAfter returns the wait requested by a Retry-After header in seconds
+func parseRetryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
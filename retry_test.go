@@ -0,0 +1,98 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestWithRetryPolicy(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make([]byte, r.ContentLength)
+		_, _ = r.Body.Read(body)
+		assert.Equal(t, `{"name":"jane"}`, string(body))
+
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	reader := sdkmetric.NewManualReader()
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithRetryPolicy(interserviceclient.RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		}),
+		interserviceclient.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	assert.Nil(t, err)
+
+	err = interserviceclient.Call(context.Background(), client, http.MethodPut, "users", map[string]string{"name": "jane"})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+
+	metrics := collectMetrics(t, reader)
+	assert.Equal(t, int64(2), sumFor(t, metrics["isc.client.retries"], attribute.String("isc.reason", "server_error")))
+}
+
+func TestWithRetryPolicy_GivesUp(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithRetryPolicy(interserviceclient.RetryPolicy{
+			MaxAttempts:    2,
+			InitialBackoff: time.Millisecond,
+		}),
+	)
+	assert.Nil(t, err)
+
+	err = interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil)
+	assert.Equal(t, http.StatusTooManyRequests, err.(*interserviceclient.ISCError).StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+
+	// non idempotent requests that reached the dependency are not retried
+	atomic.StoreInt32(&attempts, 0)
+	err = interserviceclient.Call(context.Background(), client, http.MethodPost, "users", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
+
+func TestWithRetryPolicy_RetryAfter(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithRetryPolicy(interserviceclient.RetryPolicy{MaxAttempts: 3}),
+	)
+	assert.Nil(t, err)
+
+	// the dependency asked for a longer wait than the policy allows
+	err = interserviceclient.Call(context.Background(), client, http.MethodGet, "users", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
@@ -1,19 +1,13 @@
 package interserviceclient
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"net/http"
-	"net/http/httputil"
 	"regexp"
 	"strings"
 
 	"github.com/savannahghi/converterandformatter"
-	"github.com/savannahghi/serverutils"
 )
 
 const (
@@ -25,7 +19,7 @@ const (
 
 // SmsISC is a representation of an ISC client
 type SmsISC struct {
-	Isc      *InterServiceClient
+	Isc      Client
 	EndPoint string
 }
 
@@ -51,37 +45,38 @@ func SendSMS(ctx context.Context, phoneNumbers []string, message string, smsClie
 	}
 
 	if len(foreignPhoneNos) >= 1 {
-		err := makeRequest(ctx, foreignPhoneNos, message, twilioClient.EndPoint, *twilioClient.Isc)
+		err := makeRequest(ctx, foreignPhoneNos, message, twilioClient.EndPoint, twilioClient.Isc)
 		if err != nil {
-			return fmt.Errorf("sms not sent: %v", err)
+			return fmt.Errorf("sms not sent: %w", err)
 		}
 	}
 
 	if len(localPhoneNos) >= 1 {
-		err := makeRequest(ctx, localPhoneNos, message, smsClient.EndPoint, *smsClient.Isc)
+		err := makeRequest(ctx, localPhoneNos, message, smsClient.EndPoint, smsClient.Isc)
 		if err != nil {
-			return fmt.Errorf("sms not sent: %v", err)
+			return fmt.Errorf("sms not sent: %w", err)
 		}
 	}
 
 	return nil
 }
 
-func makeRequest(ctx context.Context, phoneNumbers []string, message, EndPoint string, client InterServiceClient) error {
+func makeRequest(ctx context.Context, phoneNumbers []string, message, EndPoint string, client Client) error {
 	payload := map[string]interface{}{
 		"to":      phoneNumbers,
 		"message": message,
 	}
-	resp, err := client.MakeRequest(ctx, http.MethodPost, EndPoint, payload)
+	err := Call(
+		ctx,
+		client,
+		http.MethodPost,
+		EndPoint,
+		payload,
+		ExpectStatus(http.StatusOK),
+		WithOperationName("SendSMS"),
+	)
 	if err != nil {
-		return err
-	}
-	if serverutils.IsDebug() {
-		b, _ := httputil.DumpResponse(resp, true)
-		log.Println(string(b))
-	}
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unable to send SMS : %w, with status code %v", err, resp.StatusCode)
+		return fmt.Errorf("unable to send SMS: %w", err)
 	}
 	return nil
 }
@@ -106,8 +101,8 @@ func IsMSISDNValid(msisdn string) bool {
 }
 
 // VerifyOTP confirms a phone number is valid by verifying the code that was sent to the number
-func VerifyOTP(ctx context.Context, msisdn string, otp string, otpClient *InterServiceClient) (bool, error) {
-	if otpClient == nil {
+func VerifyOTP(ctx context.Context, msisdn string, otp string, otpClient Client) (bool, error) {
+	if isNilClient(otpClient) {
 		return false, fmt.Errorf("nil OTP client")
 	}
 
@@ -126,68 +121,44 @@ func VerifyOTP(ctx context.Context, msisdn string, otp string, otpClient *InterS
 		VerificationCode: otp,
 	}
 
-	resp, err := otpClient.MakeRequest(ctx, http.MethodPost, VerifyOTPEndPoint, verifyPayload)
-	if err != nil {
-		return false, fmt.Errorf(
-			"can't complete OTP verification request: %w", err)
-	}
-
-	if serverutils.IsDebug() {
-		b, _ := httputil.DumpResponse(resp, true)
-		log.Println(string(b))
-	}
-	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("unable to verify OTP : %w, with status code %v", err, resp.StatusCode)
-	}
-
-	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return false, fmt.Errorf("can't read OTP response data: %w", err)
-	}
-
 	type otpResponse struct {
 		IsVerified bool `json:"IsVerified"`
 	}
 
-	var r otpResponse
-	err = json.Unmarshal(data, &r)
+	r, err := Do[otpResponse](
+		ctx,
+		otpClient,
+		http.MethodPost,
+		VerifyOTPEndPoint,
+		verifyPayload,
+		ExpectStatus(http.StatusOK),
+		WithOperationName("VerifyOTP"),
+	)
 	if err != nil {
-		return false, fmt.Errorf(
-			"can't unmarshal OTP response data from JSON: %w", err)
+		return false, fmt.Errorf("unable to verify OTP: %w", err)
 	}
 
 	return r.IsVerified, nil
 }
 
 // SendOTPHelper is a helper used in tests to send OTP to a test number
-func SendOTPHelper(ctx context.Context, msisdn string, otpClient *InterServiceClient) (string, error) {
+func SendOTPHelper(ctx context.Context, msisdn string, otpClient Client) (string, error) {
 	// we prepare the OTP payload
 	payload := map[string]interface{}{
 		"msisdn": msisdn,
 	}
-	// make the request
-	resp, err := otpClient.MakeRequest(ctx, http.MethodPost, SendOTPEndPoint, payload)
-	if err != nil {
-		return "", fmt.Errorf("unable to make a send otp request: %w", err)
-	}
-	// inspect the response
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unable to generate otp, with status code %v", resp.StatusCode)
-	}
-
-	// read the response
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	// make the request and decode the OTP from the response
+	OTPResp, err := Do[string](
+		ctx,
+		otpClient,
+		http.MethodPost,
+		SendOTPEndPoint,
+		payload,
+		ExpectStatus(http.StatusOK),
+		WithOperationName("SendOTP"),
+	)
 	if err != nil {
-		return "", fmt.Errorf("unable to convert response to string: %v", err)
-	}
-	// reset the response body to the original unread state so that decode can
-	// continue
-	resp.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
-
-	// store the response in a variable and return
-	var OTPResp string
-	if err := json.NewDecoder(resp.Body).Decode(&OTPResp); err != nil {
-		return "", fmt.Errorf("InternalServerError: unable to decode verify OTP response: %v", err)
+		return "", fmt.Errorf("unable to generate otp: %w", err)
 	}
 
 	return OTPResp, nil
@@ -2,9 +2,12 @@ package interserviceclient_test
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
 )
 
 const testPhone = "+254723002959"
@@ -176,3 +179,19 @@ func TestVerifyOTP(t *testing.T) {
 		})
 	}
 }
+
+func TestSendSMS_ISCError(t *testing.T) {
+	fake := interserviceclient.NewFakeClient("engagement")
+	fake.Respond(http.MethodPost, "internal/send_sms", http.StatusNotFound, map[string]interface{}{
+		"message": "endpoint not found",
+	})
+	client := interserviceclient.SmsISC{Isc: fake, EndPoint: "internal/send_sms"}
+
+	err := interserviceclient.SendSMS(context.Background(), []string{"+254711223344"}, "hello", client, client)
+	assert.NotNil(t, err)
+	assert.True(t, interserviceclient.IsNotFound(err))
+
+	var iscErr *interserviceclient.ISCError
+	assert.True(t, errors.As(err, &iscErr))
+	assert.Equal(t, "endpoint not found", iscErr.Message)
+}
@@ -0,0 +1,142 @@
+package interserviceclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// MultipartFile is a file sent in a multipart/form-data upload
+type MultipartFile struct {
+	// The name of the form field that holds the file
+	FieldName string
+
+	// The name of the file as seen by the receiving service
+	FileName string
+
+	// The media type of the file. It defaults to `application/octet-stream`
+	ContentType string
+
+	// The file contents. It is read as the request is sent
+	Content io.Reader
+}
+
+// MakeStreamRequest performs an inter service http request whose body is streamed from the
+// supplied reader with the given content type. The caller is responsible for closing the
+// response body
+func (c InterServiceClient) MakeStreamRequest(
+	ctx context.Context,
+	method string,
+	path string,
+	contentType string,
+	body io.Reader,
+	opts ...RequestOption,
+) (*http.Response, error) {
+	opts = append(opts, WithBodyReader(body, contentType))
+	return c.DoRequest(ctx, method, path, nil, opts...)
+}
+
+// UploadFiles sends form fields and files to a dependency as a multipart/form-data POST request.
+// The files are streamed to the dependency as they are read. The caller is responsible for
+// closing the response body
+func (c InterServiceClient) UploadFiles(
+	ctx context.Context,
+	path string,
+	fields map[string]string,
+	files []MultipartFile,
+	opts ...RequestOption,
+) (*http.Response, error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		pw.CloseWithError(writeMultipart(mw, fields, files))
+	}()
+
+	resp, err := c.MakeStreamRequest(ctx, http.MethodPost, path, mw.FormDataContentType(), pr, opts...)
+	if err != nil {
+		// unblock the writer if the request failed before the body was fully read
+		pr.CloseWithError(err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// writeMultipart writes the form fields and files to a multipart writer and closes it
+func writeMultipart(mw *multipart.Writer, fields map[string]string, files []MultipartFile) error {
+	for key, value := range fields {
+		if err := mw.WriteField(key, value); err != nil {
+			return fmt.Errorf("can't write form field %s: %w", key, err)
+		}
+	}
+
+	for _, file := range files {
+		if file.Content == nil {
+			return fmt.Errorf("nil content for file %s", file.FileName)
+		}
+
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		header := make(textproto.MIMEHeader)
+		header.Set(
+			"Content-Disposition",
+			fmt.Sprintf(
+				`form-data; name="%s"; filename="%s"`,
+				escapeQuotes(file.FieldName),
+				escapeQuotes(file.FileName),
+			),
+		)
+		header.Set("Content-Type", contentType)
+
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return fmt.Errorf("can't create form part for file %s: %w", file.FileName, err)
+		}
+		if _, err := io.Copy(part, file.Content); err != nil {
+			return fmt.Errorf("can't write file %s: %w", file.FileName, err)
+		}
+	}
+
+	return mw.Close()
+}
+
+// escapeQuotes escapes a value for use in a Content-Disposition header
+func escapeQuotes(s string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(s)
+}
+
+// Stream performs an inter service request and returns the response body without buffering it,
+// for example to proxy a large export. The status code is checked in the same way as Do and
+// the caller is responsible for closing the returned body.
+func Stream(
+	ctx context.Context,
+	client Client,
+	method string,
+	path string,
+	body interface{},
+	opts ...RequestOption,
+) (io.ReadCloser, error) {
+	if isNilClient(client) {
+		return nil, fmt.Errorf("nil ISC client")
+	}
+
+	resp, err := client.DoRequest(ctx, method, path, body, opts...)
+	if err != nil {
+		return nil, transportError(client.DependencyName(), method, path, err)
+	}
+
+	expectedStatus := newRequestOptions(opts).expectedStatus
+	if !isExpectedStatus(resp.StatusCode, expectedStatus) {
+		_, err := readResponse(client.DependencyName(), resp, expectedStatus...)
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
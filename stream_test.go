@@ -0,0 +1,102 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterServiceClient_MakeStreamRequest(t *testing.T) {
+	var gotContentType string
+	var gotBody []byte
+	var gotAuth string
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		gotAuth = r.Header.Get("Authorization")
+		gotBody, _ = io.ReadAll(r.Body)
+	})
+
+	resp, err := client.MakeStreamRequest(
+		context.Background(),
+		http.MethodPut,
+		"documents/1",
+		"application/pdf",
+		strings.NewReader("%PDF-1.7"),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, resp.Body.Close())
+
+	assert.Equal(t, "application/pdf", gotContentType)
+	assert.Equal(t, "%PDF-1.7", string(gotBody))
+	assert.True(t, strings.HasPrefix(gotAuth, "Bearer "))
+}
+
+func TestInterServiceClient_UploadFiles(t *testing.T) {
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		file, header, err := r.FormFile("photo")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"owner":"` + r.FormValue("owner") + `","file":"` + header.Filename +
+			`","type":"` + header.Header.Get("Content-Type") + `","content":"` + string(content) + `"}`))
+	})
+
+	resp, err := client.UploadFiles(
+		context.Background(),
+		"uploads",
+		map[string]string{"owner": "user-1"},
+		[]interserviceclient.MultipartFile{
+			{
+				FieldName:   "photo",
+				FileName:    "avatar.png",
+				ContentType: "image/png",
+				Content:     strings.NewReader("png-bytes"),
+			},
+		},
+	)
+	assert.Nil(t, err)
+
+	got, err := interserviceclient.DecodeResponse[map[string]string](resp)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"owner":   "user-1",
+		"file":    "avatar.png",
+		"type":    "image/png",
+		"content": "png-bytes",
+	}, got)
+}
+
+func TestStream(t *testing.T) {
+	ctx := context.Background()
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("id,name\n1,test\n"))
+	})
+
+	body, err := interserviceclient.Stream(ctx, client, http.MethodGet, "export", nil)
+	assert.Nil(t, err)
+	data, err := io.ReadAll(body)
+	assert.Nil(t, err)
+	assert.Nil(t, body.Close())
+	assert.Equal(t, "id,name\n1,test\n", string(data))
+
+	_, err = interserviceclient.Stream(ctx, client, http.MethodGet, "missing", nil)
+	assert.True(t, interserviceclient.IsNotFound(err))
+}
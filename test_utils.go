@@ -2,12 +2,9 @@ package interserviceclient
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"strconv"
-	"strings"
+	"os"
 	"testing"
 
 	"firebase.google.com/go/auth"
@@ -52,22 +49,48 @@ func GetInterserviceBearerTokenHeader(t *testing.T, rootDomain string, serviceNa
 }
 
 // GetInterserviceClient returns an isc client used in acceptance testing
-func GetInterserviceClient(t *testing.T, rootDomain string, serviceName string) *InterServiceClient {
+func GetInterserviceClient(
+	t *testing.T,
+	rootDomain string,
+	serviceName string,
+	opts ...ClientOption,
+) *InterServiceClient {
 	service := ISCService{
 		Name:       serviceName,
 		RootDomain: rootDomain,
 	}
-	isc, err := NewInterserviceClient(service)
+	isc, err := NewInterserviceClient(service, opts...)
 	assert.Nil(t, err)
 	assert.NotNil(t, isc)
 	return isc
 }
 
+// UseCassette returns a client option that records the test's requests to the cassette file
+// at path and replays them on later runs. The mode can be overridden with the
+// CassetteModeEnv environment variable. The cassette is saved when the test completes
+func UseCassette(t *testing.T, path string, opts ...CassetteOption) ClientOption {
+	if mode := os.Getenv(CassetteModeEnv); mode != "" {
+		opts = append(opts, WithCassetteMode(CassetteMode(mode)))
+	}
+
+	cassette, err := NewCassette(path, opts...)
+	if err != nil {
+		t.Fatalf("unable to load cassette: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := cassette.Save(); err != nil {
+			t.Errorf("unable to save cassette: %v", err)
+		}
+	})
+
+	return WithTransport(cassette)
+}
+
 // GetPhoneNumberAuthenticatedContextAndToken returns a phone number logged in context
 // and an auth Token that contains the the test user UID useful for test purposes
 func GetPhoneNumberAuthenticatedContextAndToken(
 	t *testing.T,
-	onboardingClient *InterServiceClient,
+	onboardingClient Client,
 ) (context.Context, *auth.Token, error) {
 	ctx := context.Background()
 	userResponse, err := CreateOrLoginTestPhoneNumberUser(t, onboardingClient)
@@ -85,7 +108,7 @@ func GetPhoneNumberAuthenticatedContextAndToken(
 // and an auth Token that contains the the test user UID useful for test purposes
 func GetTestAuthorizedContextAndToken(
 	t *testing.T,
-	onboardingClient *InterServiceClient,
+	onboardingClient Client,
 ) (context.Context, *auth.Token, error) {
 	ctx := context.Background()
 	userResponse, err := CreateOrLoginTestPhoneNumberAuthorizedUser(t, onboardingClient)
@@ -113,7 +136,7 @@ func GetDefaultHeaders(t *testing.T, rootDomain string, serviceName string) map[
 func VerifyTestPhoneNumber(
 	t *testing.T,
 	phone string,
-	onboardingClient *InterServiceClient,
+	onboardingClient Client,
 ) (string, error) {
 	ctx := context.Background()
 
@@ -122,30 +145,16 @@ func VerifyTestPhoneNumber(
 		"appID":       uuid.NewString(),
 	}
 
-	resp, err := onboardingClient.MakeRequest(
+	otp, err := Do[profileutils.OtpResponse](
 		ctx,
+		onboardingClient,
 		http.MethodPost,
 		verifyPhone,
 		verifyPhonePayload,
+		ExpectStatus(http.StatusOK),
 	)
-
-	if err != nil {
-		return "", fmt.Errorf("unable to make a verify phone number request: %w", err)
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("unable to convert response to string: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("%s", string(body))
-	}
-
-	var otp profileutils.OtpResponse
-	err = json.Unmarshal(body, &otp)
 	if err != nil {
-		return "", fmt.Errorf("failed to unmarshal OTP: %v", err)
+		return "", fmt.Errorf("unable to verify phone number: %w", err)
 	}
 
 	return otp.OTP, nil
@@ -158,7 +167,7 @@ func LoginTestPhoneUser(
 	phone string,
 	PIN string,
 	flavour feedlib.Flavour,
-	onboardingClient *InterServiceClient,
+	onboardingClient Client,
 ) (*profileutils.UserResponse, error) {
 	ctx := context.Background()
 
@@ -168,32 +177,16 @@ func LoginTestPhoneUser(
 		"flavour":     flavour,
 	}
 
-	resp, err := onboardingClient.MakeRequest(
+	response, err := Do[*profileutils.UserResponse](
 		ctx,
+		onboardingClient,
 		http.MethodPost,
 		loginByPhone,
 		loginPayload,
+		ExpectStatus(http.StatusOK),
 	)
-
-	if err != nil {
-		return nil, fmt.Errorf("unable to make a login request: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unable to login : %s, with status code %v",
-			phone,
-			resp.StatusCode,
-		)
-	}
-	code, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("unable to convert response to string: %v", err)
-	}
-
-	var response *profileutils.UserResponse
-	err = json.Unmarshal(code, &response)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal OTP: %v", err)
+		return nil, fmt.Errorf("unable to login %s: %w", phone, err)
 	}
 
 	return response, nil
@@ -202,7 +195,7 @@ func LoginTestPhoneUser(
 // UpdateBioData adds Bio Data to our test user
 func UpdateBioData(
 	t *testing.T,
-	onboardingClient *InterServiceClient,
+	onboardingClient Client,
 	UID string,
 ) error {
 	ctx := context.Background()
@@ -215,24 +208,16 @@ func UpdateBioData(
 		"dateOfBirth": "2000-01-01",
 	}
 
-	resp, err := onboardingClient.MakeRequest(
+	err := Call(
 		ctx,
+		onboardingClient,
 		http.MethodPost,
 		updateBioData,
 		bioDataPayload,
+		ExpectStatus(http.StatusOK),
 	)
-
 	if err != nil {
-		return fmt.Errorf("unable to make update user profile request: %w", err)
-	}
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("unable to convert response to string: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("got status code %v with resp body: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("unable to update user profile: %w", err)
 	}
 	return nil
 }
@@ -241,22 +226,19 @@ func UpdateBioData(
 // do not exist or `Logs them in` if the test user exists to retrieve
 // authenticated user response
 // For documentation and test purposes only
-func CreateOrLoginTestPhoneNumberUser(t *testing.T, onboardingClient *InterServiceClient) (*profileutils.UserResponse, error) {
+func CreateOrLoginTestPhoneNumberUser(t *testing.T, onboardingClient Client) (*profileutils.UserResponse, error) {
 
 	phone := TestUserPhoneNumber
 	PIN := TestUserPin
 	flavour := feedlib.FlavourConsumer
 
-	if onboardingClient == nil {
+	if isNilClient(onboardingClient) {
 		return nil, fmt.Errorf("nil ISC client")
 	}
 
 	otp, err := VerifyTestPhoneNumber(t, phone, onboardingClient)
 	if err != nil {
-		if strings.Contains(
-			err.Error(),
-			strconv.Itoa(int(errorcodeutil.PhoneNumberInUse)),
-		) {
+		if code, ok := ErrorCodeOf(err); ok && code == errorcodeutil.PhoneNumberInUse {
 			userResponse, err := LoginTestPhoneUser(
 				t,
 				phone,
@@ -286,7 +268,7 @@ func CreateOrLoginTestPhoneNumberUser(t *testing.T, onboardingClient *InterServi
 // do not exist or `Logs them in` if the test user exists to retrieve
 // authenticated user response
 // For documentation and test purposes only
-func CreateOrLoginTestPhoneNumberAuthorizedUser(t *testing.T, onboardingClient *InterServiceClient) (*profileutils.UserResponse, error) {
+func CreateOrLoginTestPhoneNumberAuthorizedUser(t *testing.T, onboardingClient Client) (*profileutils.UserResponse, error) {
 	userResponse, err := CreateOrLoginTestPhoneNumberUser(t, onboardingClient)
 	if err != nil {
 		return nil, err
@@ -296,7 +278,7 @@ func CreateOrLoginTestPhoneNumberAuthorizedUser(t *testing.T, onboardingClient *
 }
 
 // CreateTestPhoneNumberUser creates the user for test phone number
-func CreateTestPhoneNumberUser(t *testing.T, onboardingClient *InterServiceClient, otp string) (*profileutils.UserResponse, error) {
+func CreateTestPhoneNumberUser(t *testing.T, onboardingClient Client, otp string) (*profileutils.UserResponse, error) {
 	ctx := context.Background()
 
 	phone := TestUserPhoneNumber
@@ -313,32 +295,16 @@ func CreateTestPhoneNumberUser(t *testing.T, onboardingClient *InterServiceClien
 		"otp":         otp,
 	}
 
-	resp, err := onboardingClient.MakeRequest(
+	response, err := Do[*profileutils.UserResponse](
 		ctx,
+		onboardingClient,
 		http.MethodPost,
 		createUserByPhone,
 		createUserPayload,
+		ExpectStatus(http.StatusCreated),
 	)
-
 	if err != nil {
-		return nil, fmt.Errorf("unable to make a sign up request: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("unable to sign up : %s, with status code %v",
-			phone,
-			resp.StatusCode,
-		)
-	}
-	signUpResp, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("unable to convert response to string: %v", err)
-	}
-
-	var response *profileutils.UserResponse
-	err = json.Unmarshal(signUpResp, &response)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal OTP: %v", err)
+		return nil, fmt.Errorf("unable to sign up %s: %w", phone, err)
 	}
 	updateUserProfilePayload := map[string]interface{}{
 		"uid":       response.Auth.UID,
@@ -346,8 +312,9 @@ func CreateTestPhoneNumberUser(t *testing.T, onboardingClient *InterServiceClien
 		"lastName":  lastName,
 		"gender":    gender,
 	}
-	resp, err = onboardingClient.MakeRequest(
+	_, err = Do[*profileutils.UserProfile](
 		ctx,
+		onboardingClient,
 		http.MethodPost,
 		updateUserDetails,
 		updateUserProfilePayload,
@@ -355,16 +322,6 @@ func CreateTestPhoneNumberUser(t *testing.T, onboardingClient *InterServiceClien
 	if err != nil {
 		return nil, fmt.Errorf("unable to update user details: %w", err)
 	}
-	updateDetailsResp, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("unable to convert response to string: %v", err)
-	}
-
-	var userProfile *profileutils.UserProfile
-	err = json.Unmarshal(updateDetailsResp, &userProfile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal OTP: %v", err)
-	}
 
 	return response, nil
 }
@@ -373,11 +330,11 @@ func CreateTestPhoneNumberUser(t *testing.T, onboardingClient *InterServiceClien
 // test phonenumber user
 func RemoveTestPhoneNumberUser(
 	t *testing.T,
-	onboardingClient *InterServiceClient,
+	onboardingClient Client,
 ) error {
 	ctx := context.Background()
 
-	if onboardingClient == nil {
+	if isNilClient(onboardingClient) {
 		return fmt.Errorf("nil ISC client")
 	}
 
@@ -393,6 +350,7 @@ func RemoveTestPhoneNumberUser(
 	if err != nil {
 		return fmt.Errorf("unable to make a request to remove test user: %w", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return nil // This is a test utility. Do not block if the user is not found
@@ -405,9 +363,9 @@ func RemoveTestPhoneNumberUser(
 // test phonenumber user
 func RemoveTestPhoneNumberAuthorizedUser(
 	t *testing.T,
-	onboardingClient *InterServiceClient,
+	onboardingClient Client,
 ) error {
-	if onboardingClient == nil {
+	if isNilClient(onboardingClient) {
 		return fmt.Errorf("nil ISC client")
 	}
 
@@ -418,7 +376,7 @@ func RemoveTestPhoneNumberAuthorizedUser(
 // GraphQL acceptance tests
 func GetTestGraphQLHeaders(
 	t *testing.T,
-	onboardingClient *InterServiceClient,
+	onboardingClient Client,
 ) (map[string]string, error) {
 	authorization, err := GetTestBearerTokenHeader(t, onboardingClient)
 	if err != nil {
@@ -435,7 +393,7 @@ func GetTestGraphQLHeaders(
 // GraphQL acceptance tests
 func GetTestBearerTokenHeader(
 	t *testing.T,
-	onboardingClient *InterServiceClient,
+	onboardingClient Client,
 ) (string, error) {
 	user, err := CreateOrLoginTestPhoneNumberUser(t, onboardingClient)
 	if err != nil {
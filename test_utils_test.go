@@ -3,6 +3,7 @@ package interserviceclient_test
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"testing"
 
 	"github.com/savannahghi/interserviceclient"
@@ -98,12 +99,16 @@ func TestGetInterserviceBearerTokenHeader(t *testing.T) {
 		})
 	}
 }
-func onboardingISCClient() (*interserviceclient.InterServiceClient, error) {
+// onboardingISCClient returns an onboarding client that replays the named cassette from
+// testdata/cassettes. Set ISC_CASSETTE_MODE=record to re-record it against the onboarding service
+func onboardingISCClient(t *testing.T, cassette string) (*interserviceclient.InterServiceClient, error) {
 	onboardingClient, err := interserviceclient.NewInterserviceClient(
 		interserviceclient.ISCService{
 			Name:       OnboardingName,
 			RootDomain: OnboardingRootDomain,
-		})
+		},
+		interserviceclient.UseCassette(t, filepath.Join("testdata", "cassettes", cassette+".json")),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize onboarding ISC client: %v", err)
 	}
@@ -111,7 +116,7 @@ func onboardingISCClient() (*interserviceclient.InterServiceClient, error) {
 }
 
 func TestVerifyTestPhoneNumber(t *testing.T) {
-	onboardingClient, err := onboardingISCClient()
+	onboardingClient, err := onboardingISCClient(t, "verify_test_phone_number")
 	if err != nil {
 		t.Errorf("failed to initialize onboarding test ISC client")
 	}
@@ -166,7 +171,7 @@ func TestVerifyTestPhoneNumber(t *testing.T) {
 }
 
 func TestCreateOrLoginTestPhoneNumberUser(t *testing.T) {
-	onboardingClient, err := onboardingISCClient()
+	onboardingClient, err := onboardingISCClient(t, "create_or_login_test_phone_number_user")
 	if err != nil {
 		t.Errorf("failed to initialize onboarding test ISC client")
 	}
@@ -230,7 +235,7 @@ func TestCreateOrLoginTestPhoneNumberUser(t *testing.T) {
 }
 
 func TestRemoveTestPhoneNumberUser(t *testing.T) {
-	onboardingClient, err := onboardingISCClient()
+	onboardingClient, err := onboardingISCClient(t, "remove_test_phone_number_user")
 	if err != nil {
 		t.Errorf("failed to initialize onboarding test ISC client")
 		return
@@ -280,7 +285,7 @@ func TestRemoveTestPhoneNumberUser(t *testing.T) {
 }
 
 func TestUpdateBioData(t *testing.T) {
-	onboardingClient, err := onboardingISCClient()
+	onboardingClient, err := onboardingISCClient(t, "update_bio_data")
 	if err != nil {
 		t.Errorf("failed to initialize onboarding test ISC client")
 	}
@@ -337,7 +342,7 @@ func TestUpdateBioData(t *testing.T) {
 }
 
 func TestCreateOrLoginTestPhoneNumberAuthorizedUser(t *testing.T) {
-	onboardingClient, err := onboardingISCClient()
+	onboardingClient, err := onboardingISCClient(t, "create_or_login_test_phone_number_authorized_user")
 	if err != nil {
 		t.Errorf("failed to initialize onboarding test ISC client")
 	}
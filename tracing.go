@@ -0,0 +1,160 @@
+package interserviceclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// span attribute keys
+const (
+	operationKey   = attribute.Key("isc.operation")
+	callerKey      = attribute.Key("isc.caller")
+	peerServiceKey = attribute.Key("peer.service")
+	authOutcomeKey = attribute.Key("isc.auth.outcome")
+	authReasonKey  = attribute.Key("isc.auth.reason")
+)
+
+// WithServiceName sets the name of the service making requests. It is sent to dependencies in
+// the CallerServiceHeader header and recorded on spans
+func WithServiceName(name string) ClientOption {
+	return func(o *clientOptions) {
+		o.serviceName = name
+	}
+}
+
+// WithPropagators sets the propagators used to send trace context to dependencies.
+// The default is the global propagator
+func WithPropagators(propagators propagation.TextMapPropagator) ClientOption {
+	return func(o *clientOptions) {
+		o.propagators = propagators
+	}
+}
+
+// WithOperationName sets the logical operation performed by a request e.g `RegisterUser`.
+// Spans are named after the dependency and the operation
+func WithOperationName(operation string) RequestOption {
+	return func(o *requestOptions) {
+		o.operation = operation
+	}
+}
+
+// WithMiddlewareTracerProvider sets the tracer provider used by TracingMiddleware.
+// The default is the global tracer provider
+func WithMiddlewareTracerProvider(tracerProvider trace.TracerProvider) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.tracerProvider = tracerProvider
+	}
+}
+
+// WithMiddlewarePropagators sets the propagators used by TracingMiddleware to extract the
+// caller's trace context. The default is the global propagator
+func WithMiddlewarePropagators(propagators propagation.TextMapPropagator) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.propagators = propagators
+	}
+}
+
+// newTracer returns the tracer used by a client
+func newTracer(tracerProvider trace.TracerProvider) trace.Tracer {
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+	return tracerProvider.Tracer(instrumentationName)
+}
+
+// startSpan starts the span that covers an inter service request, from sending it to closing
+// the response body
+func (c InterServiceClient) startSpan(
+	ctx context.Context,
+	method string,
+	route string,
+	options *requestOptions,
+) (context.Context, trace.Span) {
+	if c.tracer == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+
+	operation := options.operation
+	if operation == "" {
+		operation = fmt.Sprintf("%s %s", method, route)
+	}
+
+	attrs := []attribute.KeyValue{
+		dependencyKey.String(c.Name),
+		peerServiceKey.String(c.Name),
+		operationKey.String(operation),
+		methodKey.String(method),
+		routeKey.String(route),
+	}
+	if c.serviceName != "" {
+		attrs = append(attrs, callerKey.String(c.serviceName))
+	}
+
+	return c.tracer.Start(
+		ctx,
+		fmt.Sprintf("%s %s", c.Name, operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// recordResponse records the outcome of an inter service request on its span
+func recordResponse(span trace.Span, resp *http.Response, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	span.SetAttributes(statusKey.Int(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+}
+
+// TracingMiddleware starts a server span for each inter service request, continuing the trace
+// started by the caller. The span is named after the operation and records the calling service.
+// It should wrap InterServiceAuthenticationMiddleware so that the authentication outcome is
+// recorded on the span
+func TracingMiddleware(operation string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	options := newMiddlewareOptions(opts)
+
+	otelOpts := []otelhttp.Option{}
+	if options.tracerProvider != nil {
+		otelOpts = append(otelOpts, otelhttp.WithTracerProvider(options.tracerProvider))
+	}
+	if options.propagators != nil {
+		otelOpts = append(otelOpts, otelhttp.WithPropagators(options.propagators))
+	}
+
+	return func(next http.Handler) http.Handler {
+		withCaller := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if caller := r.Header.Get(CallerServiceHeader); caller != "" {
+				trace.SpanFromContext(r.Context()).SetAttributes(callerKey.String(caller))
+			}
+			next.ServeHTTP(w, r)
+		})
+		return otelhttp.NewHandler(withCaller, operation, otelOpts...)
+	}
+}
+
+// recordAuthOutcome records the outcome of an authentication check on the current span
+func recordAuthOutcome(ctx context.Context, reason string) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	if reason == "" {
+		span.SetAttributes(authOutcomeKey.String("success"))
+		return
+	}
+	span.SetAttributes(authOutcomeKey.String("failure"), authReasonKey.String(reason))
+}
@@ -0,0 +1,100 @@
+package interserviceclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/savannahghi/interserviceclient"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanAttribute returns the value of a span attribute
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+// spanNamed returns the ended span with the supplied name
+func spanNamed(t *testing.T, recorder *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			return span
+		}
+	}
+	t.Fatalf("no span named %q", name)
+	return nil
+}
+
+func TestInterServiceClient_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	propagators := propagation.TraceContext{}
+
+	handler := interserviceclient.TracingMiddleware(
+		"register_user",
+		interserviceclient.WithMiddlewareTracerProvider(tracerProvider),
+		interserviceclient.WithMiddlewarePropagators(propagators),
+	)(interserviceclient.InterServiceAuthenticationMiddleware()(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{}`))
+		}),
+	))
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	client, err := interserviceclient.NewInterserviceClient(
+		interserviceclient.ISCService{Name: "profile", RootDomain: srv.URL},
+		interserviceclient.WithTracerProvider(tracerProvider),
+		interserviceclient.WithPropagators(propagators),
+		interserviceclient.WithServiceName("onboarding"),
+	)
+	assert.Nil(t, err)
+
+	onboarding := interserviceclient.NewOnboardingService(client)
+	_, err = onboarding.RegisterUser(context.Background(), map[string]string{"firstName": "Test"})
+	assert.Nil(t, err)
+
+	clientSpan := spanNamed(t, recorder, "profile RegisterUser")
+	assert.Equal(t, trace.SpanKindClient, clientSpan.SpanKind())
+	assert.Equal(t, "profile", spanAttribute(clientSpan, "isc.dependency").AsString())
+	assert.Equal(t, "profile", spanAttribute(clientSpan, "peer.service").AsString())
+	assert.Equal(t, "onboarding", spanAttribute(clientSpan, "isc.caller").AsString())
+	assert.Equal(t, int64(http.StatusOK), spanAttribute(clientSpan, "http.status_code").AsInt64())
+
+	serverSpan := spanNamed(t, recorder, "register_user")
+	assert.Equal(t, trace.SpanKindServer, serverSpan.SpanKind())
+	assert.Equal(t, clientSpan.SpanContext().TraceID(), serverSpan.SpanContext().TraceID())
+	assert.Equal(t, "onboarding", spanAttribute(serverSpan, "isc.caller").AsString())
+	assert.Equal(t, "success", spanAttribute(serverSpan, "isc.auth.outcome").AsString())
+}
+
+func TestTracingMiddleware_AuthFailure(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	handler := interserviceclient.TracingMiddleware(
+		"register_user",
+		interserviceclient.WithMiddlewareTracerProvider(tracerProvider),
+	)(interserviceclient.InterServiceAuthenticationMiddleware()(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	))
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+
+	serverSpan := spanNamed(t, recorder, "register_user")
+	assert.Equal(t, "failure", spanAttribute(serverSpan, "isc.auth.outcome").AsString())
+	assert.Equal(t, "missing_token", spanAttribute(serverSpan, "isc.auth.reason").AsString())
+}